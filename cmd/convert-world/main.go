@@ -0,0 +1,23 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"rpg/game"
+)
+
+func main() {
+	filename := flag.String("world", "game/maps/world.txt", "legacy world file")
+	flag.Parse()
+
+	world, err := game.ReadLegacyWorld(*filename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := world.Write(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"rpg/game"
+	"rpg/game/generator"
+)
+
+func main() {
+	conf := generator.DefaultConf()
+	flag.Int64Var(&conf.Seed, "seed", 1, "generator seed")
+	flag.IntVar(&conf.Width, "width", conf.Width, "level width")
+	flag.IntVar(&conf.Height, "height", conf.Height, "level height")
+	flag.IntVar(&conf.Rooms, "rooms", conf.Rooms, "number of rooms")
+	flag.IntVar(&conf.Monsters, "monsters", conf.Monsters, "number of monsters")
+	flag.IntVar(&conf.Items, "items", conf.Items, "number of items on the ground")
+	flag.IntVar(&conf.Chests, "chests", conf.Chests, "number of chests")
+	winding := flag.Bool("winding", false, "dig winding corridors instead of L-shaped ones")
+	cave := flag.Bool("cave", false, "generate a natural cave instead of rooms and corridors")
+	dataDir := flag.String("data", game.DefaultGameConf().DataDir, "directory with monster and item definitions")
+	flag.Parse()
+
+	itemDefs, bestiary, err := game.LoadDefinitions(*dataDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	conf.Bestiary, conf.ItemDefs = bestiary, itemDefs
+	if *winding {
+		conf.Corridors = generator.CWinding
+	}
+
+	generate := generator.Generate
+	if *cave {
+		generate = generator.GenerateCave
+	}
+	dungeon, err := generate(conf, game.NewPlayer(game.Pos{}))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	// stairs positions are needed for linking the level in world.ini
+	fmt.Fprintln(os.Stderr, "up stairs:", dungeon.Up.X, dungeon.Up.Y)
+	fmt.Fprintln(os.Stderr, "down stairs:", dungeon.Down.X, dungeon.Down.Y)
+	if err := dungeon.Level.WriteMap(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"rpg/game"
+)
+
+var directions = []game.DirectionType{game.DUp, game.DDown, game.DLeft, game.DRight}
+var diagonals = []game.DirectionType{game.DUpLeft, game.DUpRight, game.DDownLeft, game.DDownRight}
+
+type stats struct {
+	kills        int
+	damageDealt  int
+	damageTaken  int
+	itemsPicked  int
+	doorsOpened  int
+	levelChanges int
+}
+
+func (s *stats) handle(event game.Event) {
+	switch event.Kind {
+	case game.Attack:
+		if event.Actor == "Player" {
+			s.damageDealt += event.Damage
+			if event.Killed {
+				s.kills++
+			}
+		} else {
+			s.damageTaken += event.Damage
+		}
+	case game.PickUp:
+		s.itemsPicked++
+	case game.DoorOpen:
+		s.doorsOpened++
+	case game.Portal:
+		s.levelChanges++
+	}
+}
+
+func main() {
+	conf := game.DefaultGameConf()
+	flag.Int64Var(&conf.Seed, "seed", 1, "run seed")
+	turns := flag.Int("turns", 1000, "number of turns to simulate")
+	botSeed := flag.Int64("bot-seed", 1, "seed of the random walking bot")
+	recordFile := flag.String("record", "", "record all inputs into this replay file")
+	replayFile := flag.String("replay", "", "play back a replay file instead of running the bot")
+	flag.BoolVar(&conf.Diagonal, "diagonal", false, "allow diagonal movement")
+	explore := flag.Bool("explore", false, "let the bot auto-explore between random steps")
+	flag.Parse()
+	if conf.Diagonal {
+		directions = append(directions, diagonals...)
+	}
+
+	var g *game.Game
+	var err error
+	s := &stats{}
+	if *replayFile != "" {
+		g, err = replay(*replayFile, s)
+	} else {
+		g, err = simulate(conf, *turns, *botSeed, *explore, *recordFile, s)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	for _, line := range g.CurrentLevel.Log {
+		fmt.Println(line)
+	}
+	fmt.Printf("seed: %d, hitpoints: %d, items: %d, position: %v\n", g.Seed(), g.Player.Hitpoints, len(g.Player.Items), g.Player.Pos)
+	fmt.Printf("kills: %d, damage dealt: %d, damage taken: %d, items picked: %d, doors opened: %d, level changes: %d\n",
+		s.kills, s.damageDealt, s.damageTaken, s.itemsPicked, s.doorsOpened, s.levelChanges)
+}
+
+func replay(filename string, s *stats) (*game.Game, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return game.Replay(file, s.handle)
+}
+
+func simulate(conf *game.GameConf, turns int, botSeed int64, explore bool, recordFile string, s *stats) (*game.Game, error) {
+	g, err := game.NewGame(conf)
+	if err != nil {
+		return nil, err
+	}
+	g.Subscribe(s.handle)
+	if recordFile != "" {
+		file, err := os.Create(recordFile)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		if err := g.Record(file); err != nil {
+			return nil, err
+		}
+	}
+	g.Start()
+
+	bot := rand.New(rand.NewSource(botSeed))
+	for turn := 0; turn < turns && g.Player.IsAlive(); turn++ {
+		input := &game.Input{Typ: game.IMove, Direction: directions[bot.Intn(len(directions))]}
+		view := g.View()
+		if items := view.Items[view.Player.Pos]; len(items) > 0 {
+			input = &game.Input{Typ: game.ITakeItem, ItemID: items[0].ID}
+		} else if explore && view.Traveling {
+			input = &game.Input{Typ: game.IContinueTravel}
+		} else if explore && turn%2 == 0 {
+			input = &game.Input{Typ: game.IExplore}
+		}
+		g.Step(input)
+	}
+	g.Step(&game.Input{Typ: game.IQuitGame})
+	return g, nil
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"rpg/game"
+)
+
+func main() {
+	conf := game.DefaultGameConf()
+	flag.StringVar(&conf.MapsDir, "maps", conf.MapsDir, "directory with *.map files and world.ini")
+	flag.Parse()
+
+	report := game.Validate(conf)
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if !report.OK {
+		os.Exit(1)
+	}
+}
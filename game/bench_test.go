@@ -0,0 +1,64 @@
+package game_test
+
+import (
+	"rpg/game"
+	"rpg/game/generator"
+	"testing"
+)
+
+// benchLevel generates a large level crowded with monsters, the player waits on the up stairs
+func benchLevel(b *testing.B) (*game.Level, *game.Player) {
+	itemDefs, bestiary, err := game.LoadDefinitions("data")
+	if err != nil {
+		b.Fatal(err)
+	}
+	conf := generator.DefaultConf()
+	conf.Seed = 1
+	conf.Width, conf.Height = 120, 80
+	conf.Rooms, conf.Monsters = 24, 40
+	conf.Bestiary, conf.ItemDefs = bestiary, itemDefs
+
+	player := game.NewPlayer(game.Pos{})
+	dungeon, err := generator.Generate(conf, player)
+	if err != nil {
+		b.Fatal(err)
+	}
+	player.Pos = dungeon.Up
+	if len(dungeon.Level.Monsters) == 0 {
+		b.Fatal("no monsters generated")
+	}
+	return dungeon.Level, player
+}
+
+// one monster turn: every monster looks for its next step towards the player
+func BenchmarkAStar(b *testing.B) {
+	level, player := benchLevel(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, monster := range level.Monsters {
+			level.Path(monster.Pos, player.Pos)
+		}
+	}
+}
+
+func BenchmarkFlowField(b *testing.B) {
+	level, player := benchLevel(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dist := level.NewDistanceMap(player.Pos)
+		for _, monster := range level.Monsters {
+			level.Downhill(dist, monster.Pos)
+		}
+	}
+}
+
+func BenchmarkFOV(b *testing.B) {
+	level, player := benchLevel(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		level.FOV(player.Pos, player.SightRange)
+	}
+}
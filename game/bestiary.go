@@ -0,0 +1,230 @@
+package game
+
+import (
+	"strconv"
+	"strings"
+)
+
+const monstersFile = "monsters.ini"
+
+type AIProfile int
+
+const (
+	// AIHunter closes in and attacks
+	AIHunter AIProfile = iota
+	// AICoward flees once it lost half of its hitpoints
+	AICoward
+	// AISkirmisher keeps its distance and strikes only when the player gets next to it
+	AISkirmisher
+)
+
+var aiProfiles = map[string]AIProfile{
+	"hunter":     AIHunter,
+	"coward":     AICoward,
+	"skirmisher": AISkirmisher,
+}
+
+// tiles a skirmisher keeps between itself and the player
+const skirmishDistance = 3
+
+type Sprite struct {
+	X, Y, Count int
+}
+
+type Loot struct {
+	Item   *ItemDef
+	Chance float64
+}
+
+type MonsterDef struct {
+	Name       string
+	Rune       rune
+	Sprite     Sprite
+	Hitpoints  int
+	Strength   int
+	Speed      float64
+	SightRange int
+	Light      int
+	AI         AIProfile
+	Loot       []Loot
+	// sound files played on attack, death and notice events of the monster
+	Sounds map[string]string
+
+	line int
+}
+
+type Bestiary struct {
+	index defIndex
+}
+
+// LoadBestiary needs the item definitions to resolve the loot of monsters
+func LoadBestiary(filename string, itemDefs *ItemRegistry) (*Bestiary, error) {
+	bestiary := &Bestiary{index: newDefIndex()}
+	// incomplete monsters are reported in the order they appear in the file
+	var sections []*MonsterDef
+	var errs ErrorList
+	errs.add(readINI(filename, func(header []string, line int) setter {
+		if len(header) != 2 || header[0] != "monster" {
+			return nil
+		}
+		def := &MonsterDef{Name: header[1], Speed: 1, SightRange: 10, Sounds: make(map[string]string), line: line}
+		sections = append(sections, def)
+		return bestiary.define(def, itemDefs)
+	}))
+	for _, def := range sections {
+		if def.Rune == 0 || def.Sprite.Count == 0 {
+			errs.add(&LoadError{File: filename, Line: def.line, Err: ErrMissingField})
+		}
+	}
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	return bestiary, nil
+}
+
+// define registers the monster once its rune is known and returns the setter of its fields
+func (bestiary *Bestiary) define(def *MonsterDef, itemDefs *ItemRegistry) setter {
+	return func(key, value string) error {
+		var err error
+		switch key {
+		case "rune":
+			r, err := bestiary.index.parseRune(value)
+			if err != nil {
+				return err
+			}
+			// map entities are told apart by their rune alone
+			if _, item := itemDefs.Lookup(r); item {
+				return ErrDuplicateKey
+			}
+			def.Rune = r
+			bestiary.index.add(def)
+		case "sprite":
+			def.Sprite, err = parseSprite(value)
+		case "hitpoints":
+			def.Hitpoints, err = parseInt(value)
+		case "strength":
+			def.Strength, err = parseInt(value)
+		case "speed":
+			def.Speed, err = parseFloat(value)
+		case "sight":
+			def.SightRange, err = parseInt(value)
+		case "light":
+			def.Light, err = parseInt(value)
+		case "ai":
+			profile, exists := aiProfiles[value]
+			if !exists {
+				return ErrUnknownField
+			}
+			def.AI = profile
+		case "loot":
+			def.Loot, err = parseLoot(value, itemDefs)
+		case "sound.attack", "sound.death", "sound.notice":
+			def.Sounds[strings.TrimPrefix(key, "sound.")] = value
+		default:
+			return ErrUnknownField
+		}
+		return err
+	}
+}
+
+func (bestiary *Bestiary) Lookup(r rune) (*MonsterDef, bool) {
+	def, exists := bestiary.index.runes[r]
+	if !exists {
+		return nil, false
+	}
+	return def.(*MonsterDef), true
+}
+
+// Named finds the monster type of a saved monster
+func (bestiary *Bestiary) Named(name string) (*MonsterDef, bool) {
+	def, exists := bestiary.index.names[name]
+	if !exists {
+		return nil, false
+	}
+	return def.(*MonsterDef), true
+}
+
+// Defs lists the monsters ordered by rune, the generator picks from it by index
+// so the same seed populates levels with the same monsters
+func (bestiary *Bestiary) Defs() []*MonsterDef {
+	defs := make([]*MonsterDef, len(bestiary.index.sorted))
+	for i, def := range bestiary.index.sorted {
+		defs[i] = def.(*MonsterDef)
+	}
+	return defs
+}
+
+func (def *MonsterDef) Spawn(pos Pos) *Monster {
+	monster := &Monster{kind: def}
+	monster.Pos = pos
+	monster.Rune = def.Rune
+	monster.Name = def.Name
+	monster.Hitpoints = def.Hitpoints
+	monster.MaxHitpoints = def.Hitpoints
+	monster.Strength = def.Strength
+	monster.Speed = def.Speed
+	monster.ActionPoints = 0.0
+	monster.SightRange = def.SightRange
+	monster.Light = def.Light
+	monster.Awareness = Unaware
+	return monster
+}
+
+func parseSprite(value string) (Sprite, error) {
+	fields := strings.Split(value, ",")
+	if len(fields) != 3 {
+		return Sprite{}, ErrMissingField
+	}
+	numbers := make([]int, 3)
+	for i, field := range fields {
+		n, err := parseInt(field)
+		if err != nil {
+			return Sprite{}, err
+		}
+		numbers[i] = n
+	}
+	if numbers[2] < 1 {
+		return Sprite{}, ErrInvalidNumber
+	}
+	return Sprite{numbers[0], numbers[1], numbers[2]}, nil
+}
+
+// loot is a list of item runes with drop chances like "s 0.5, h 0.1"
+func parseLoot(value string, itemDefs *ItemRegistry) ([]Loot, error) {
+	var loot []Loot
+	for _, entry := range strings.Split(value, ",") {
+		fields := strings.Fields(entry)
+		if len(fields) != 2 {
+			return nil, ErrMissingField
+		}
+		if len(fields[0]) != 1 {
+			return nil, ErrInvalidRune
+		}
+		item, exists := itemDefs.Lookup(rune(fields[0][0]))
+		if !exists || item.Slot == Key {
+			return nil, ErrInvalidRune
+		}
+		chance, err := parseFloat(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		loot = append(loot, Loot{item, chance})
+	}
+	return loot, nil
+}
+
+func parseInt(value string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, ErrInvalidNumber
+	}
+	return n, nil
+}
+
+func parseFloat(value string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, ErrInvalidNumber
+	}
+	return f, nil
+}
@@ -1,15 +1,19 @@
 package game
 
-import "strconv"
+import "math/rand"
 
 type Character struct {
 	Repository
 
 	Hitpoints    int
+	MaxHitpoints int
+	// turns left of healing one hitpoint per turn
+	Regeneration int
 	Strength     int
 	Speed        float64
 	ActionPoints float64
 	SightRange   int
+	Light        int
 
 	Helmet *Item
 	Weapon *Item
@@ -20,33 +24,44 @@ func (c *Character) IsAlive() bool {
 	return c.Hitpoints > 0
 }
 
-func (c *Character) Attack(cToAttack *Character) string {
-	attackPower := c.Strength
+func (c *Character) Attack(cToAttack *Character, rng *rand.Rand) Event {
+	damage := c.Strength
 	if c.Weapon != nil {
-		attackPower = int(float64(attackPower) * c.Weapon.Power)
+		damage += c.Weapon.kind.rollDamage(rng)
 	}
-	damage := attackPower
-	if cToAttack.Helmet != nil {
-		damage = int(float64(damage) * (1.0 - cToAttack.Helmet.Power))
-	}
-	if cToAttack.Armor != nil {
-		damage = int(float64(damage) * (1.0 - cToAttack.Armor.Power))
+	// armor never blocks a hit completely
+	damage -= cToAttack.armor()
+	if damage < 1 {
+		damage = 1
 	}
 
 	cToAttack.Hitpoints -= damage
-	if cToAttack.IsAlive() {
-		return c.Name + " hits " + cToAttack.Name + " causing damage " + strconv.Itoa(damage)
-	} else {
-		return c.Name + " killed " + cToAttack.Name + " causing damage " + strconv.Itoa(damage)
+	return Event{
+		Kind:   Attack,
+		Actor:  c.Name,
+		Target: cToAttack.Name,
+		Pos:    cToAttack.Pos,
+		Damage: damage,
+		Killed: !cToAttack.IsAlive(),
 	}
 }
 
+func (c *Character) armor() int {
+	armor := 0
+	for _, item := range []*Item{c.Helmet, c.Weapon, c.Armor} {
+		if item != nil {
+			armor += item.kind.Armor
+		}
+	}
+	return armor
+}
+
 func (c *Character) TakeItem(level *Level, itemToMove *Item) bool {
 	items := level.Items[c.Pos]
 	for i, item := range items {
 		if item == itemToMove {
 			level.Items[c.Pos] = append(items[:i], items[i+1:]...)
-			c.Items = append(c.Items, itemToMove)
+			c.Items = stack(c.Items, itemToMove)
 			return true
 		}
 	}
@@ -58,7 +73,7 @@ func (c *Character) DropItem(level *Level, itemToMove *Item) bool {
 		if item == itemToMove {
 			item.Pos = c.Pos
 			c.Items = append(c.Items[:i], c.Items[i+1:]...)
-			level.Items[c.Pos] = append(level.Items[c.Pos], item)
+			level.Items[c.Pos] = stack(level.Items[c.Pos], item)
 			return true
 		}
 	}
@@ -72,7 +87,7 @@ func (c *Character) StoreItem(level *Level, itemToMove *Item) bool {
 			if item == itemToMove {
 				item.Pos = c.Pos
 				c.Items = append(c.Items[:i], c.Items[i+1:]...)
-				storage.Items = append(storage.Items, item)
+				storage.Items = stack(storage.Items, item)
 				return true
 			}
 		}
@@ -86,7 +101,7 @@ func (c *Character) WithdrawItem(level *Level, itemToMove *Item) bool {
 		for i, item := range storage.Items {
 			if item == itemToMove {
 				storage.Items = append(storage.Items[:i], storage.Items[i+1:]...)
-				c.Items = append(c.Items, itemToMove)
+				c.Items = stack(c.Items, itemToMove)
 				return true
 			}
 		}
@@ -94,6 +109,11 @@ func (c *Character) WithdrawItem(level *Level, itemToMove *Item) bool {
 	return false
 }
 
+// carries tells whether the item is in the inventory or equipped
+func (c *Character) carries(item *Item) bool {
+	return holds(c.Items, item) || item == c.Helmet || item == c.Weapon || item == c.Armor
+}
+
 func (c *Character) Equip(itemToEquip *Item) bool {
 	for i, item := range c.Items {
 		if item == itemToEquip {
@@ -0,0 +1,103 @@
+package game
+
+type Effect int
+
+const (
+	NoEffect Effect = iota
+	// EHeal restores hitpoints at once
+	EHeal
+	// ERegenerate heals one hitpoint per turn for a while
+	ERegenerate
+	// ETeleport moves the user to a random free tile of the level
+	ETeleport
+)
+
+var effects = map[string]Effect{
+	"heal":       EHeal,
+	"regenerate": ERegenerate,
+	"teleport":   ETeleport,
+}
+
+func (c *Character) heal(amount int) int {
+	if c.Hitpoints+amount > c.MaxHitpoints {
+		amount = c.MaxHitpoints - c.Hitpoints
+	}
+	if amount < 0 {
+		amount = 0
+	}
+	c.Hitpoints += amount
+	return amount
+}
+
+func (c *Character) consume(itemToUse *Item) bool {
+	for i, item := range c.Items {
+		if item == itemToUse {
+			if item.Quantity > 1 {
+				item.Quantity--
+			} else {
+				c.Items = append(c.Items[:i], c.Items[i+1:]...)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// only consumables carried in the inventory can be used
+func (game *Game) useItem(item *Item) ActionType {
+	p := game.Player
+	if item.Typ != Consumable || !p.consume(item) {
+		return ANone
+	}
+	level := game.CurrentLevel
+	game.emitItem(Use, item)
+
+	switch item.kind.Effect {
+	case EHeal:
+		healed := p.heal(item.kind.Amount)
+		level.emit(Event{Kind: Heal, Actor: p.Name, Pos: p.Pos, Damage: healed})
+	case ERegenerate:
+		p.Regeneration += item.kind.Amount
+	case ETeleport:
+		if pos, ok := level.teleportTarget(); ok {
+			from := p.Pos
+			p.Move(pos, level)
+			level.emit(Event{Kind: Teleport, Actor: p.Name, Pos: pos, From: from, To: pos})
+			level.resolveVisibility()
+		}
+	}
+	return AUseItem
+}
+
+// the target stays reachable so the player cannot end up walled in
+func (level *Level) teleportTarget() (Pos, bool) {
+	reachable := level.Reachable(level.Player.Pos)
+	candidates := make([]Pos, 0)
+	for y, row := range level.Map {
+		for x := range row {
+			pos := Pos{x, y}
+			if !reachable[pos] || !level.canWalk(pos) || pos == level.Player.Pos {
+				continue
+			}
+			if _, portal := level.Portals[pos]; portal {
+				continue
+			}
+			if _, occupied := level.AliveMonstersPos[pos]; occupied {
+				continue
+			}
+			candidates = append(candidates, pos)
+		}
+	}
+	if len(candidates) == 0 {
+		return Pos{}, false
+	}
+	return candidates[level.rng.Intn(len(candidates))], true
+}
+
+// regenerate is called once per turn of the player
+func (c *Character) regenerate() {
+	if c.Regeneration > 0 {
+		c.Regeneration--
+		c.heal(1)
+	}
+}
@@ -0,0 +1,52 @@
+package game
+
+import "sort"
+
+// definition is an item or monster type read from the data files
+type definition interface {
+	key() rune
+	name() string
+}
+
+func (def *ItemDef) key() rune       { return def.Rune }
+func (def *ItemDef) name() string    { return def.Name }
+func (def *MonsterDef) key() rune    { return def.Rune }
+func (def *MonsterDef) name() string { return def.Name }
+
+// defIndex finds definitions by the rune used in maps and by the name used in saves,
+// it is filled once while loading so lookups never have to scan or sort
+type defIndex struct {
+	runes  map[rune]definition
+	names  map[string]definition
+	sorted []definition
+}
+
+func newDefIndex() defIndex {
+	return defIndex{runes: make(map[rune]definition), names: make(map[string]definition)}
+}
+
+// parseRune checks the rune of a new definition before any field of it is set
+func (index *defIndex) parseRune(value string) (rune, error) {
+	if len(value) != 1 || reservedRune(rune(value[0])) {
+		return 0, ErrInvalidRune
+	}
+	r := rune(value[0])
+	if _, duplicate := index.runes[r]; duplicate {
+		return 0, ErrDuplicateKey
+	}
+	return r, nil
+}
+
+func (index *defIndex) add(def definition) {
+	index.runes[def.key()] = def
+	// a name shared by several definitions resolves to the one with the lowest rune
+	if other, exists := index.names[def.name()]; !exists || def.key() < other.key() {
+		index.names[def.name()] = def
+	}
+	i := sort.Search(len(index.sorted), func(i int) bool {
+		return index.sorted[i].key() > def.key()
+	})
+	index.sorted = append(index.sorted, nil)
+	copy(index.sorted[i+1:], index.sorted[i:])
+	index.sorted[i] = def
+}
@@ -0,0 +1,86 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidRune     = errors.New("invalid rune")
+	ErrInvalidNumber   = errors.New("invalid number")
+	ErrMissingField    = errors.New("missing field")
+	ErrOutOfBounds     = errors.New("position out of bounds")
+	ErrUnknownLevel    = errors.New("unknown level")
+	ErrEmptyMap        = errors.New("empty map")
+	ErrMissingStart    = errors.New("missing start level")
+	ErrDuplicatePortal = errors.New("duplicate portal")
+	ErrDuplicateKey    = errors.New("duplicate key")
+	ErrNoDoor          = errors.New("no closed door to lock")
+	ErrStairMismatch   = errors.New("down stairs do not match up stairs of the next floor")
+	ErrUnknownField    = errors.New("unknown field")
+	ErrBlockedStart    = errors.New("start is not on a free walkable tile")
+	ErrPortalSource    = errors.New("portal entry is out of bounds")
+	ErrPortalTarget    = errors.New("portal destination is out of bounds")
+)
+
+type LoadError struct {
+	File   string
+	Line   int
+	Column int
+	Rune   rune
+	Level  string
+	Err    error
+}
+
+func (e *LoadError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.File)
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ":%d", e.Line)
+		if e.Column > 0 {
+			fmt.Fprintf(&b, ":%d", e.Column)
+		}
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Err.Error())
+	if e.Rune != 0 {
+		fmt.Fprintf(&b, " %q", e.Rune)
+	}
+	if e.Level != "" {
+		fmt.Fprintf(&b, " %q", e.Level)
+	}
+	return b.String()
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+type ErrorList []error
+
+func (list ErrorList) Error() string {
+	messages := make([]string, len(list))
+	for i, err := range list {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (list *ErrorList) add(err error) {
+	if err == nil {
+		return
+	}
+	if nested, ok := err.(ErrorList); ok {
+		*list = append(*list, nested...)
+	} else {
+		*list = append(*list, err)
+	}
+}
+
+func (list ErrorList) err() error {
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
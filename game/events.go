@@ -0,0 +1,61 @@
+package game
+
+type GameEvent int
+
+const (
+	Move GameEvent = iota
+	DoorOpen
+	DoorClose
+	Attack
+	Portal
+	PickUp
+	DropDown
+	Equip
+	TakeOff
+	Notice
+	Locked
+	Unlock
+	Use
+	Heal
+	Teleport
+	Overloaded
+)
+
+type Event struct {
+	Kind   GameEvent
+	Actor  string
+	Target string
+	// Pos is where the event happened: the target, door or item position
+	Pos    Pos
+	Item   *ItemView
+	Damage int
+	Killed bool
+
+	FromLevel string
+	From      Pos
+	ToLevel   string
+	To        Pos
+}
+
+type EventHandler func(event Event)
+
+func (game *Game) Subscribe(handler EventHandler) {
+	game.subscribers = append(game.subscribers, handler)
+}
+
+func (level *Level) emit(event Event) {
+	level.LastEvents = append(level.LastEvents, event)
+}
+
+func (game *Game) emitItem(kind GameEvent, item *Item) {
+	view := game.itemView(item)
+	game.CurrentLevel.emit(Event{Kind: kind, Actor: game.Player.Name, Pos: game.Player.Pos, Item: &view})
+}
+
+func (game *Game) publish() {
+	for _, event := range game.CurrentLevel.LastEvents {
+		for _, handler := range game.subscribers {
+			handler(event)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testConf points to the shipped data relative to the package directory
+func testConf() *GameConf {
+	conf := DefaultGameConf()
+	conf.MapsDir, conf.LangDir, conf.DataDir = "maps", "lang", "data"
+	conf.Seed = 1
+	return conf
+}
+
+// writeFixtures writes the files named by their keys into a fresh directory
+func writeFixtures(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
@@ -0,0 +1,119 @@
+package game
+
+import "math"
+
+const unreachable = math.MaxInt32
+
+// DistanceMap holds for every tile the cost of reaching the nearest goal,
+// monsters walk downhill on it
+type DistanceMap [][]int
+
+func (level *Level) newDistanceMap() DistanceMap {
+	dist := make(DistanceMap, len(level.Map))
+	for y, row := range level.Map {
+		dist[y] = make([]int, len(row))
+		for x := range row {
+			dist[y][x] = unreachable
+		}
+	}
+	return dist
+}
+
+func (level *Level) NewDistanceMap(goals ...Pos) DistanceMap {
+	dist := level.newDistanceMap()
+	for _, goal := range goals {
+		if level.inRange(goal) {
+			dist[goal.Y][goal.X] = 0
+		}
+	}
+	level.relax(dist)
+	return dist
+}
+
+// FleeMap turns an approach map into one leading away from its goals,
+// the coefficient below -1 makes fleeing monsters prefer open space over dead ends
+func (level *Level) FleeMap(approach DistanceMap) DistanceMap {
+	dist := level.newDistanceMap()
+	for y, row := range approach {
+		for x, d := range row {
+			if d != unreachable {
+				dist[y][x] = -d * 6 / 5
+			}
+		}
+	}
+	level.relax(dist)
+	return dist
+}
+
+// KiteMap leads to tiles keeping the given distance from the goals of an approach map
+func (level *Level) KiteMap(approach DistanceMap, distance int) DistanceMap {
+	dist := level.newDistanceMap()
+	for y, row := range approach {
+		for x, d := range row {
+			if d != unreachable {
+				dist[y][x] = int(math.Abs(float64(d - distance)))
+			}
+		}
+	}
+	level.relax(dist)
+	return dist
+}
+
+// Dijkstra seeded with every finite value of the map
+func (level *Level) relax(dist DistanceMap) {
+	frontier := make(pqueue, 0, 64)
+	for y, row := range dist {
+		for x, d := range row {
+			if d != unreachable {
+				frontier = frontier.push(Pos{x, y}, d)
+			}
+		}
+	}
+
+	var current Pos
+	for len(frontier) > 0 {
+		frontier, current = frontier.pop()
+		cost := dist[current.Y][current.X] + 1
+		for _, next := range level.getNeighbors(current) {
+			if cost < dist[next.Y][next.X] {
+				dist[next.Y][next.X] = cost
+				frontier = frontier.push(next, cost)
+			}
+		}
+	}
+}
+
+func (dist DistanceMap) At(pos Pos) int {
+	if pos.Y < 0 || pos.Y >= len(dist) || pos.X < 0 || pos.X >= len(dist[pos.Y]) {
+		return unreachable
+	}
+	return dist[pos.Y][pos.X]
+}
+
+// Downhill picks the lowest neighbor not taken by another monster
+func (level *Level) Downhill(dist DistanceMap, from Pos) (Pos, bool) {
+	best, found := from, false
+	for _, next := range level.getNeighbors(from) {
+		if _, occupied := level.AliveMonstersPos[next]; occupied {
+			continue
+		}
+		if dist.At(next) < dist.At(best) {
+			best, found = next, true
+		}
+	}
+	return best, found
+}
+
+func (level *Level) approachMap() DistanceMap {
+	if level.approach == nil {
+		level.approach = level.NewDistanceMap(level.Player.Pos)
+	}
+	return level.approach
+}
+
+func (level *Level) fleeMap() DistanceMap {
+	if level.flee == nil {
+		level.flee = level.FleeMap(level.approachMap())
+	}
+	return level.flee
+}
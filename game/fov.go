@@ -0,0 +1,79 @@
+package game
+
+// symmetric shadowcasting, see https://www.albertford.com/shadowcasting/
+
+type slope struct {
+	num, den int
+}
+
+type quadrant struct {
+	origin Pos
+	dir    DirectionType
+}
+
+func (q quadrant) transform(depth, col int) Pos {
+	switch q.dir {
+	case DUp:
+		return Pos{q.origin.X + col, q.origin.Y - depth}
+	case DDown:
+		return Pos{q.origin.X + col, q.origin.Y + depth}
+	case DLeft:
+		return Pos{q.origin.X - depth, q.origin.Y + col}
+	default:
+		return Pos{q.origin.X + depth, q.origin.Y + col}
+	}
+}
+
+func (level *Level) FOV(origin Pos, radius int) []Pos {
+	visible := []Pos{origin}
+	seen := map[Pos]bool{origin: true}
+	reveal := func(pos Pos) {
+		if !seen[pos] {
+			seen[pos] = true
+			visible = append(visible, pos)
+		}
+	}
+
+	for _, dir := range []DirectionType{DUp, DDown, DLeft, DRight} {
+		level.scanRow(quadrant{origin, dir}, 1, slope{-1, 1}, slope{1, 1}, radius, reveal)
+	}
+	return visible
+}
+
+func (level *Level) scanRow(q quadrant, depth int, start, end slope, radius int, reveal func(Pos)) {
+	if depth > radius {
+		return
+	}
+
+	minCol := floorDiv(2*depth*start.num+start.den, 2*start.den)
+	maxCol := -floorDiv(-(2*depth*end.num - end.den), 2*end.den)
+	wasOpaque, first := false, true
+	for col := minCol; col <= maxCol; col++ {
+		pos := q.transform(depth, col)
+		opaque := !level.canSeeThrough(pos)
+		inRadius := depth*depth+col*col <= radius*radius
+		symmetric := col*start.den >= depth*start.num && col*end.den <= depth*end.num
+		if level.inRange(pos) && inRadius && (opaque || symmetric) {
+			reveal(pos)
+		}
+
+		if !first && wasOpaque && !opaque {
+			start = slope{2*col - 1, 2 * depth}
+		}
+		if !first && !wasOpaque && opaque {
+			level.scanRow(q, depth+1, start, slope{2*col - 1, 2 * depth}, radius, reveal)
+		}
+		wasOpaque, first = opaque, false
+	}
+	if !first && !wasOpaque {
+		level.scanRow(q, depth+1, start, end, radius, reveal)
+	}
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,49 @@
+package game
+
+import "testing"
+
+func TestFOVSymmetry(t *testing.T) {
+	game := newTestGame(t,
+		"############",
+		"#@...#.....#",
+		"#..#....#..#",
+		"#.....##...#",
+		"#.#........#",
+		"#....#..#..#",
+		"############",
+	)
+	level := game.CurrentLevel
+	const radius = 8
+
+	var floors []Pos
+	sees := make(map[Pos]map[Pos]bool)
+	for y, row := range level.Map {
+		for x := range row {
+			pos := Pos{x, y}
+			if !level.canSeeThrough(pos) {
+				continue
+			}
+			floors = append(floors, pos)
+			sees[pos] = make(map[Pos]bool)
+			for _, visible := range level.FOV(pos, radius) {
+				sees[pos][visible] = true
+			}
+		}
+	}
+
+	// whoever can be seen can see back, monsters rely on it to notice the player
+	for _, a := range floors {
+		for _, b := range floors {
+			if sees[a][b] != sees[b][a] {
+				t.Errorf("%v sees %v: %v, the other way round: %v", a, b, sees[a][b], sees[b][a])
+			}
+		}
+	}
+
+	if sees[Pos{1, 1}][Pos{6, 1}] {
+		t.Error("wall did not block the sight")
+	}
+	if !sees[Pos{1, 1}][Pos{1, 5}] {
+		t.Error("open floor is hidden")
+	}
+}
@@ -1,41 +1,126 @@
 package game
 
 import (
-	"encoding/csv"
-	"os"
+	"encoding/json"
+	"math/rand"
 	"path/filepath"
-	"strconv"
 	"strings"
+	"time"
 )
 
 type Game struct {
-	LevelChan    chan *Level
+	LevelChan    chan *LevelView
 	InputChan    chan *Input
 	Player       *Player
 	Levels       map[string]*Level
 	CurrentLevel *Level
+
+	conf         GameConf
+	rng          *rand.Rand
+	randomSource *countingSource
+	recorder     *json.Encoder
+	subscribers  []EventHandler
+	catalog      *Catalog
+	bestiary     *Bestiary
+	itemDefs     *ItemRegistry
+	autoTravel   *autoTravel
+}
+
+func newGame(conf *GameConf, draws uint64, player *Player, levels map[string]*Level) *Game {
+	game := &Game{
+		LevelChan: make(chan *LevelView),
+		InputChan: make(chan *Input),
+		Player:    player,
+		Levels:    levels,
+		conf:      *conf,
+	}
+	game.rng, game.randomSource = newRandom(conf.Seed, draws)
+	for name, level := range levels {
+		if level != nil {
+			game.AddLevel(name, level)
+		}
+	}
+	game.Subscribe(game.logEvent)
+	return game
+}
+
+func (game *Game) AddLevel(name string, level *Level) {
+	level.Name = name
+	level.rng = game.rng
+	level.diagonal = game.conf.Diagonal
+	game.Levels[name] = level
+}
+
+type GameConf struct {
+	MapsDir     string
+	LangDir     string
+	DataDir     string
+	Language    string
+	Seed        int64
+	Diagonal    bool
+	ActionCosts map[ActionType]float64
+}
+
+func DefaultGameConf() *GameConf {
+	return &GameConf{
+		MapsDir:     "game/maps",
+		LangDir:     "game/lang",
+		DataDir:     "game/data",
+		Language:    "en",
+		ActionCosts: DefaultActionCosts(),
+	}
 }
 
-func NewGame() *Game {
-	levelChan := make(chan *Level)
-	inputChan := make(chan *Input)
+func NewGame(conf *GameConf) (*Game, error) {
+	game, err := loadGame(conf)
+	if err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// loadGame returns the game along with the errors of whatever failed to load,
+// broken levels stay registered as nil and the game is nil only when nothing could be read
+func loadGame(conf *GameConf) (*Game, error) {
+	seeded := *conf
+	if seeded.Seed == 0 {
+		seeded.Seed = time.Now().UnixNano()
+	}
+	conf = &seeded
 
 	levels := make(map[string]*Level)
-	filenames, err := filepath.Glob("game/maps/*.map")
+	filenames, err := filepath.Glob(filepath.Join(conf.MapsDir, "*.map"))
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
+	// maps cannot be read without knowing their monsters and items
+	itemDefs, bestiary, err := LoadDefinitions(conf.DataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs ErrorList
 	player := NewPlayer(Pos{0, 0})
 	for _, filename := range filenames {
-		extIndex := strings.LastIndex(filename, ".map")
-		lastSlashIndex := strings.LastIndex(filename, "/")
-		levelName := filename[lastSlashIndex+1 : extIndex]
-		levels[levelName] = NewLevelFromFile(filename, player)
+		levelName := strings.TrimSuffix(filepath.Base(filename), ".map")
+		level, err := NewLevelFromFile(filename, player, bestiary, itemDefs)
+		errs.add(err)
+		// broken levels stay registered as nil so the world file does not report them as unknown
+		levels[levelName] = level
 	}
-	game := &Game{levelChan, inputChan, player, levels, nil}
-	game.loadWorldFile()
-	return game
+	game := newGame(conf, 0, player, levels)
+	game.bestiary = bestiary
+	game.itemDefs = itemDefs
+	world, err := LoadWorld(conf.MapsDir)
+	if err != nil {
+		errs.add(err)
+	} else {
+		errs.add(game.applyWorld(world))
+	}
+	game.catalog, err = LoadCatalog(conf.LangDir, conf.Language)
+	errs.add(err)
+	return game, errs.err()
 }
 
 type InputType int
@@ -52,7 +137,13 @@ const (
 	IStoreItem
 	IEquipItem
 	IStripItem
+	IQuickSave
+	IQuickLoad
 	IQuitGame
+	ITravel
+	IExplore
+	IContinueTravel
+	IUseItem
 )
 
 type DirectionType int
@@ -63,12 +154,19 @@ const (
 	DDown
 	DLeft
 	DRight
+	DUpLeft
+	DUpRight
+	DDownLeft
+	DDownRight
 )
 
 type Input struct {
-	Typ       InputType
-	Item      *Item
+	Typ    InputType
+	ItemID int
+	// Quantity moves only part of a stack, zero moves all of it
+	Quantity  int
 	Direction DirectionType
+	Target    Pos
 }
 
 type Pos struct {
@@ -81,108 +179,98 @@ type Entity struct {
 	Name string
 }
 
-func (game *Game) loadWorldFile() {
-	file, err := os.Open("game/maps/world.txt")
-	if err != nil {
-		panic(err)
-	}
-	csvReader := csv.NewReader(file)
-	csvReader.FieldsPerRecord = -1
-	csvReader.TrimLeadingSpace = true
-	rows, err := csvReader.ReadAll()
-	if err != nil {
-		panic(err)
-	}
-
-	for rowIndex, row := range rows {
-		// first level
-		if rowIndex == 0 {
-			game.CurrentLevel = game.Levels[row[0]]
-			continue
-		}
-
-		// portal entry
-		level := game.Levels[row[0]]
-		x, err := strconv.ParseInt(row[1], 10, 64)
-		if err != nil {
-			panic(err)
-		}
-		y, err := strconv.ParseInt(row[2], 10, 64)
-		if err != nil {
-			panic(err)
-		}
-		pos := Pos{int(x), int(y)}
-
-		// portal destination
-		dstLevel := game.Levels[row[3]]
-		x, err = strconv.ParseInt(row[4], 10, 64)
-		if err != nil {
-			panic(err)
-		}
-		y, err = strconv.ParseInt(row[5], 10, 64)
-		if err != nil {
-			panic(err)
-		}
-		dstPos := Pos{int(x), int(y)}
-
-		// link
-		level.Portals[pos] = &LevelPos{dstLevel, dstPos}
-	}
-}
-
-func (game *Game) resolveMovement(pos Pos) {
+func (game *Game) resolveMovement(pos Pos) ActionType {
 	monster, exists := game.CurrentLevel.AliveMonstersPos[pos]
 	if exists {
-		event := game.Player.Attack(&monster.Character)
-		game.CurrentLevel.LastEvents = append(game.CurrentLevel.LastEvents, Attack)
-		game.CurrentLevel.addEvent(event)
-		if !monster.IsAlive() {
-			monster.Kill(game.CurrentLevel)
-		}
-		if !game.Player.IsAlive() {
-			game.CurrentLevel.addEvent("DED")
-		}
+		game.attack(monster)
+		return AAttack
 	} else if game.CurrentLevel.canWalk(pos) {
 		game.CurrentLevel.Player.Move(pos, game.CurrentLevel)
-		game.CurrentLevel.LastEvents = append(game.CurrentLevel.LastEvents, Move)
+		game.CurrentLevel.emit(Event{Kind: Move, Actor: game.Player.Name, Pos: pos})
 
 		portal, portalExists := game.CurrentLevel.Portals[game.Player.Pos]
 		if portalExists {
-			game.CurrentLevel = portal.level
-			game.Player.Pos = portal.pos
-			game.CurrentLevel.LastEvents = append(game.CurrentLevel.LastEvents, Portal)
+			game.travel(portal)
 		}
-		game.CurrentLevel.resetVisibility()
 		game.CurrentLevel.resolveVisibility()
-	} else {
-		game.CurrentLevel.checkClosedDoor(pos)
-		game.CurrentLevel.resetVisibility()
+		return AMove
+	} else if game.CurrentLevel.checkClosedDoor(pos) {
 		game.CurrentLevel.resolveVisibility()
+		return AOpenDoor
+	}
+	return ANone
+}
+
+func (game *Game) attack(monster *Monster) {
+	game.CurrentLevel.emit(game.Player.Attack(&monster.Character, game.rng))
+	if !monster.IsAlive() {
+		monster.Kill(game.CurrentLevel)
+	}
+}
+
+func (game *Game) travel(portal *Passage) {
+	from := game.CurrentLevel
+	// portals requiring a key only need it carried, it is not used up
+	if portal.KeyID != "" && game.Player.findKey(portal.KeyID) == nil {
+		from.emit(Event{Kind: Locked, Actor: game.Player.Name, Target: portal.Name, Pos: game.Player.Pos})
+		return
+	}
+	event := Event{
+		Kind:      Portal,
+		Actor:     game.Player.Name,
+		Pos:       portal.pos,
+		FromLevel: from.Name,
+		From:      game.Player.Pos,
+		ToLevel:   portal.level.Name,
+		To:        portal.pos,
 	}
+
+	// events of this turn travel along so they are published from the new level
+	events := from.LastEvents
+	from.LastEvents = make([]Event, 0)
+	game.CurrentLevel = portal.level
+	game.Player.Pos = portal.pos
+	game.CurrentLevel.LastEvents = append(events, event)
 }
 
-func (game *Game) resolveAction(pos Pos) {
+func (game *Game) resolveAction(pos Pos) ActionType {
 	monster, exists := game.CurrentLevel.AliveMonstersPos[pos]
 	if exists {
-		event := game.Player.Attack(&monster.Character)
-		game.CurrentLevel.LastEvents = append(game.CurrentLevel.LastEvents, Attack)
-		game.CurrentLevel.addEvent(event)
-		if !monster.IsAlive() {
-			monster.Kill(game.CurrentLevel)
-		}
-		if !game.Player.IsAlive() {
-			game.CurrentLevel.addEvent("DED")
-		}
-	} else {
-		if game.CurrentLevel.checkClosedDoor(pos) || game.CurrentLevel.checkOpenedDoor(pos) {
-			game.CurrentLevel.resetVisibility()
-			game.CurrentLevel.resolveVisibility()
-		}
+		game.attack(monster)
+		return AAttack
+	}
+
+	if game.isLocked(pos) {
+		return game.unlock(pos)
+	}
+	if portal, exists := game.CurrentLevel.Portals[pos]; exists && pos == game.Player.Pos {
+		game.travel(portal)
+		game.CurrentLevel.resolveVisibility()
+		return AMove
+	}
+
+	action := ANone
+	if game.CurrentLevel.checkClosedDoor(pos) {
+		action = AOpenDoor
+	} else if game.CurrentLevel.checkOpenedDoor(pos) {
+		action = ACloseDoor
+	}
+	if action != ANone {
+		game.CurrentLevel.resolveVisibility()
 	}
+	return action
 }
 
-func (game *Game) handleInput(input *Input) {
+func (game *Game) handleInput(input *Input) ActionType {
 	p := game.Player
+	item := game.findItem(input.ItemID)
+	switch input.Typ {
+	case ITakeItem, IDropItem, IWithdrawItem, IStoreItem, IEquipItem, IStripItem, IUseItem:
+		if item == nil {
+			return ANone
+		}
+	}
+
 	switch input.Typ {
 	case IMove, IAction:
 		var newPos Pos
@@ -195,17 +283,43 @@ func (game *Game) handleInput(input *Input) {
 			newPos = Pos{p.X - 1, p.Y}
 		case DRight:
 			newPos = Pos{p.X + 1, p.Y}
+		case DUpLeft:
+			newPos = Pos{p.X - 1, p.Y - 1}
+		case DUpRight:
+			newPos = Pos{p.X + 1, p.Y - 1}
+		case DDownLeft:
+			newPos = Pos{p.X - 1, p.Y + 1}
+		case DDownRight:
+			newPos = Pos{p.X + 1, p.Y + 1}
+		case DNone:
+			// actions can target the tile the player stands on
+			if input.Typ != IAction {
+				return ANone
+			}
+			newPos = p.Pos
+		default:
+			return ANone
+		}
+		if !game.CurrentLevel.canStep(p.Pos, newPos) {
+			return ANone
 		}
 
 		switch input.Typ {
 		case IMove:
-			game.resolveMovement(newPos)
+			return game.resolveMovement(newPos)
 		case IAction:
-			game.resolveAction(newPos)
+			return game.resolveAction(newPos)
 		}
 	case ITakeItem:
-		if game.Player.TakeItem(game.CurrentLevel, input.Item) {
-			game.CurrentLevel.LastEvents = append(game.CurrentLevel.LastEvents, PickUp)
+		// stacks are split only once the move is known to succeed
+		ground := game.CurrentLevel.Items[p.Pos]
+		if !holds(ground, item) || game.overloaded(item, input.Quantity) {
+			return ANone
+		}
+		game.CurrentLevel.Items[p.Pos], item = split(ground, item, input.Quantity)
+		if game.Player.TakeItem(game.CurrentLevel, item) {
+			game.emitItem(PickUp, item)
+			return ATakeItem
 		}
 	case ITakeAllItems:
 		if len(game.CurrentLevel.Items[game.Player.Pos]) > 0 {
@@ -213,72 +327,152 @@ func (game *Game) handleInput(input *Input) {
 			itemsCopy := make([]*Item, len(game.CurrentLevel.Items[game.Player.Pos]))
 			copy(itemsCopy, game.CurrentLevel.Items[game.Player.Pos])
 			for _, item := range itemsCopy {
-				took = game.Player.TakeItem(game.CurrentLevel, item)
+				if game.overloaded(item, 0) {
+					continue
+				}
+				if game.Player.TakeItem(game.CurrentLevel, item) {
+					game.emitItem(PickUp, item)
+					took = true
+				}
 			}
 			if took {
-				game.CurrentLevel.LastEvents = append(game.CurrentLevel.LastEvents, PickUp)
+				return ATakeItem
 			}
 		}
 	case IDropItem:
-		if game.CurrentLevel.Storages[game.CurrentLevel.Player.Pos] != nil {
-			game.Player.Strip(input.Item)
-			if game.Player.DropItem(game.CurrentLevel, input.Item) {
-				game.CurrentLevel.LastEvents = append(game.CurrentLevel.LastEvents, DropDown)
+		if game.CurrentLevel.Storages[p.Pos] != nil && p.carries(item) {
+			p.Strip(item)
+			p.Items, item = split(p.Items, item, input.Quantity)
+			if game.Player.DropItem(game.CurrentLevel, item) {
+				game.emitItem(DropDown, item)
+				return ADropItem
 			}
 		}
 	case IWithdrawItem:
-		if game.Player.WithdrawItem(game.CurrentLevel, input.Item) {
-			game.CurrentLevel.LastEvents = append(game.CurrentLevel.LastEvents, PickUp)
+		if game.storageLocked() {
+			return ANone
+		}
+		storage := game.CurrentLevel.Storages[p.Pos]
+		if storage == nil || !holds(storage.Items, item) || game.overloaded(item, input.Quantity) {
+			return ANone
+		}
+		storage.Items, item = split(storage.Items, item, input.Quantity)
+		if game.Player.WithdrawItem(game.CurrentLevel, item) {
+			game.emitItem(PickUp, item)
+			return AWithdrawItem
 		}
 	case IWithdrawAllItems:
+		if game.storageLocked() {
+			return ANone
+		}
 		took := false
 		storage := game.CurrentLevel.Storages[game.Player.Pos]
 		if storage != nil && !storage.Locked {
 			itemsCopy := make([]*Item, len(storage.Items))
 			copy(itemsCopy, storage.Items)
 			for _, item := range itemsCopy {
-				took = game.Player.WithdrawItem(game.CurrentLevel, item)
+				if game.overloaded(item, 0) {
+					continue
+				}
+				if game.Player.WithdrawItem(game.CurrentLevel, item) {
+					game.emitItem(PickUp, item)
+					took = true
+				}
 			}
 			if took {
-				game.CurrentLevel.LastEvents = append(game.CurrentLevel.LastEvents, PickUp)
+				return AWithdrawItem
 			}
 		}
 	case IStoreItem:
-		game.Player.Strip(input.Item)
-		if game.Player.StoreItem(game.CurrentLevel, input.Item) {
-			game.CurrentLevel.LastEvents = append(game.CurrentLevel.LastEvents, DropDown)
+		if game.storageLocked() {
+			return ANone
+		}
+		if game.CurrentLevel.Storages[p.Pos] == nil || !p.carries(item) {
+			return ANone
+		}
+		p.Strip(item)
+		p.Items, item = split(p.Items, item, input.Quantity)
+		if game.Player.StoreItem(game.CurrentLevel, item) {
+			game.emitItem(DropDown, item)
+			return AStoreItem
 		}
 	case IEquipItem:
-		if game.Player.Equip(input.Item) {
-			game.CurrentLevel.LastEvents = append(game.CurrentLevel.LastEvents, Equip)
+		if game.Player.Equip(item) {
+			game.emitItem(Equip, item)
+			return AEquip
 		}
 	case IStripItem:
-		if game.Player.Strip(input.Item) {
-			game.CurrentLevel.LastEvents = append(game.CurrentLevel.LastEvents, TakeOff)
+		if game.Player.Strip(item) {
+			game.emitItem(TakeOff, item)
+			return AStrip
 		}
+	case IUseItem:
+		return game.useItem(item)
+	case IQuickSave:
+		game.quickSave()
+	case IQuickLoad:
+		game.quickLoad()
+	case ITravel:
+		game.startTravel(input.Target, false)
+		return game.continueTravel()
+	case IExplore:
+		game.startTravel(Pos{}, true)
+		return game.continueTravel()
+	case IContinueTravel:
+		return game.continueTravel()
 	}
+	return ANone
 }
 
-func (game *Game) Run() {
+func (game *Game) Bestiary() *Bestiary {
+	return game.bestiary
+}
+
+func (game *Game) ItemDefs() *ItemRegistry {
+	return game.itemDefs
+}
+
+func (game *Game) Seed() int64 {
+	return game.conf.Seed
+}
+
+func (game *Game) Start() {
 	game.CurrentLevel.resolveVisibility()
-	game.LevelChan <- game.CurrentLevel
+}
+
+func (game *Game) Step(input *Input) bool {
+	game.record(input)
+	game.CurrentLevel.LastEvents = make([]Event, 0)
+	if input.Typ == IQuitGame {
+		return false
+	}
+
+	// any other command takes over from travelling
+	if input.Typ != IContinueTravel {
+		game.autoTravel = nil
+	}
+	action := game.handleInput(input)
+	if action != ANone {
+		game.Player.regenerate()
+		game.Player.ActionPoints -= game.actionCost(action)
+		game.CurrentLevel.noise(game.Player.Pos, actionNoise[action])
+		game.runMonsters()
+		// glowing monsters and picked up or equipped torches change the light
+		game.CurrentLevel.resolveVisibility()
+	}
+	game.interruptTravel()
+	game.publish()
+	return true
+}
+
+func (game *Game) Run() {
+	game.Start()
+	game.LevelChan <- game.View()
 
 	for input := range game.InputChan {
-		game.CurrentLevel.LastEvents = make([]GameEvent, 0)
-		if input.Typ == IQuitGame {
+		if !game.Step(input) {
 			return
 		}
-
-		game.handleInput(input)
-		switch input.Typ {
-		case IAction, IMove:
-			for _, monster := range game.CurrentLevel.Monsters {
-				if monster.IsAlive() {
-					monster.Update(game.CurrentLevel)
-				}
-			}
-		}
-
-		game.LevelChan <- game.CurrentLevel
+		game.LevelChan <- game.View()
 	}
 }
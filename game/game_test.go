@@ -0,0 +1,171 @@
+package game
+
+import "testing"
+
+var testRat = &MonsterDef{Name: "Rat", Rune: 'R', Hitpoints: 5, Strength: 5, Speed: 1, SightRange: 10}
+
+// newTestGame builds a single level game from rows of tile runes
+func newTestGame(t *testing.T, rows ...string) *Game {
+	player := NewPlayer(Pos{})
+	level := newTestLevel(t, player, rows...)
+
+	conf := DefaultGameConf()
+	conf.Seed = 1
+	game := newGame(conf, 0, player, map[string]*Level{"test": level})
+	game.CurrentLevel = level
+	game.catalog = &Catalog{fallbackLanguage, make(map[string]string)}
+	game.Start()
+	return game
+}
+
+// newTestLevel builds a level from rows of tile runes, '@' places the player and 'R' a rat
+func newTestLevel(t *testing.T, player *Player, rows ...string) *Level {
+	level := NewLevel(len(rows[0]), len(rows), player)
+	for y, row := range rows {
+		for x, c := range row {
+			switch c {
+			case '@':
+				player.Pos = Pos{x, y}
+				c = DirtFloor
+			case 'R':
+				level.AddMonster(testRat.Spawn(Pos{x, y}))
+				c = DirtFloor
+			}
+			if err := level.SetTile(Pos{x, y}, c); err != nil {
+				t.Fatalf("%c at %d,%d: %v", c, x, y, err)
+			}
+		}
+	}
+	level.ResolveFloors()
+	return level
+}
+
+func TestStepMove(t *testing.T) {
+	game := newTestGame(t,
+		"#####",
+		"#@..#",
+		"#####",
+	)
+	game.Step(&Input{Typ: IMove, Direction: DRight})
+	if want := (Pos{2, 1}); game.Player.Pos != want {
+		t.Errorf("player at %v, want %v", game.Player.Pos, want)
+	}
+	// walls stop the player without spending a turn
+	game.Step(&Input{Typ: IMove, Direction: DUp})
+	if want := (Pos{2, 1}); game.Player.Pos != want {
+		t.Errorf("player walked into a wall to %v", game.Player.Pos)
+	}
+}
+
+func TestStepOpensDoor(t *testing.T) {
+	game := newTestGame(t,
+		"######",
+		"#@|..#",
+		"######",
+	)
+	door := Pos{2, 1}
+	game.Step(&Input{Typ: IMove, Direction: DRight})
+	if game.Player.Pos != (Pos{1, 1}) {
+		t.Fatalf("player walked through a closed door to %v", game.Player.Pos)
+	}
+	if overlay := game.CurrentLevel.Map[door.Y][door.X].OverlayRune; overlay != OpenedDoor {
+		t.Fatalf("door shows %q after bumping into it", overlay)
+	}
+	opened := false
+	for _, event := range game.CurrentLevel.LastEvents {
+		opened = opened || event.Kind == DoorOpen
+	}
+	if !opened {
+		t.Error("no door open event")
+	}
+
+	game.Step(&Input{Typ: IMove, Direction: DRight})
+	if game.Player.Pos != door {
+		t.Errorf("player at %v, want the opened door %v", game.Player.Pos, door)
+	}
+}
+
+func TestStepMonsterTurn(t *testing.T) {
+	game := newTestGame(t,
+		"########",
+		"#@....R#",
+		"########",
+	)
+	rat := game.CurrentLevel.Monsters[0]
+	game.Step(&Input{Typ: IMove, Direction: DRight})
+	if rat.Awareness != Hunting {
+		t.Errorf("rat did not notice the player")
+	}
+	if countEvents(game.CurrentLevel, Notice) != 1 {
+		t.Errorf("no notice of the rat in plain sight")
+	}
+	if want := (Pos{5, 1}); rat.Pos != want {
+		t.Errorf("rat at %v, want %v", rat.Pos, want)
+	}
+
+	// moving into the rat attacks it instead
+	for i := 0; i < 2; i++ {
+		game.Step(&Input{Typ: IMove, Direction: DRight})
+	}
+	if rat.IsAlive() {
+		t.Errorf("rat at %v survived the player at %v", rat.Pos, game.Player.Pos)
+	}
+	if _, alive := game.CurrentLevel.AliveMonstersPos[rat.Pos]; alive {
+		t.Error("dead rat still blocks its tile")
+	}
+}
+
+func TestNoticeInDarkness(t *testing.T) {
+	game := newTestGame(t,
+		"########",
+		"#@....R#",
+		"########",
+	)
+	game.CurrentLevel.Ambient = 0
+	game.Player.Light = 0
+	game.CurrentLevel.resolveVisibility()
+	rat := game.CurrentLevel.Monsters[0]
+	game.Step(&Input{Typ: IMove, Direction: DRight})
+	if rat.Awareness != Hunting {
+		t.Fatal("rat did not notice the player")
+	}
+	if game.CurrentLevel.spotted(rat.Pos) {
+		t.Fatalf("rat at %v is visible in the dark", rat.Pos)
+	}
+	if countEvents(game.CurrentLevel, Notice) != 0 {
+		t.Error("notice of a rat the player cannot see")
+	}
+}
+
+func TestStairs(t *testing.T) {
+	game := newTestGame(t,
+		"#####",
+		"#@.d#",
+		"#####",
+	)
+	upper := game.CurrentLevel
+	lower := newTestLevel(t, game.Player,
+		"#####",
+		"#u..#",
+		"#####",
+	)
+	game.AddLevel("lower", lower)
+	if err := LinkStairs(upper, lower); err != nil {
+		t.Fatal(err)
+	}
+
+	game.Step(&Input{Typ: IMove, Direction: DRight})
+	game.Step(&Input{Typ: IMove, Direction: DRight})
+	if game.CurrentLevel != lower || game.Player.Pos != (Pos{1, 1}) {
+		t.Fatalf("player at %v on %s, want 1,1 on lower", game.Player.Pos, game.CurrentLevel.Name)
+	}
+	if countEvents(lower, Portal) != 1 {
+		t.Error("no portal event on the lower level")
+	}
+
+	// standing on the stairs the player climbs back with an action
+	game.Step(&Input{Typ: IAction, Direction: DNone})
+	if game.CurrentLevel != upper || game.Player.Pos != (Pos{3, 1}) {
+		t.Errorf("player at %v on %s, want 3,1 on test", game.Player.Pos, game.CurrentLevel.Name)
+	}
+}
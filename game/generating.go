@@ -1,6 +1,6 @@
 package game
 
-func (level *Level) generateTile(x, y int, c rune) {
+func (level *Level) generateTile(x, y int, c rune) error {
 	var t Tile
 	t.OverlayRune = Blank
 	t.canSee = true
@@ -40,49 +40,61 @@ func (level *Level) generateTile(x, y int, c rune) {
 		t.Rune = Pending
 		t.canWalk = false
 	default:
-		level.generateEntity(x, y, c)
 		t.Rune = Pending
+		if err := level.generateEntity(x, y, c, ""); err != nil {
+			return err
+		}
 	}
 	level.Map[y][x] = t
+	return nil
 }
 
-func (level *Level) generateEntity(x, y int, c rune) {
+func (level *Level) generateEntity(x, y int, c rune, key string) error {
 	pos := Pos{x, y}
-	item := level.generateItem(pos, c)
-	if item != nil {
-		level.Items[pos] = append(level.Items[pos], item)
-	} else {
-		switch c {
-		case '@':
-			level.Player.Pos = pos
-		case 'R':
-			m := NewRat(pos)
-			level.Monsters = append(level.Monsters, m)
-			level.AliveMonstersPos[pos] = m
-		case 'S':
-			m := NewSpider(pos)
-			level.Monsters = append(level.Monsters, m)
-			level.AliveMonstersPos[pos] = m
-
-		case '=':
-			level.Storages[pos] = NewChest(pos, &StorageConf{items: level.Items[pos]})
-			delete(level.Items, pos)
+	switch c {
+	case ClosedDoor:
+		if key == "" {
+			return ErrMissingField
+		}
+		if level.Map[y][x].OverlayRune != ClosedDoor {
+			return ErrNoDoor
+		}
+		level.Locks[pos] = key
+		return nil
+	}
 
-		default:
-			panic("Invalid rune: " + string(c))
+	if def, exists := level.itemDefs.Lookup(c); exists {
+		item := def.Spawn(pos)
+		// keys are useless without the id of the lock they open
+		if def.Slot == Key {
+			if key == "" {
+				return ErrMissingField
+			}
+			item.KeyID = key
+		} else if def.Stackable && key != "" {
+			quantity, err := parseInt(key)
+			if err != nil || quantity < 1 {
+				return ErrInvalidNumber
+			}
+			item.Quantity = quantity
 		}
+		level.AddItem(item)
+		return nil
 	}
-}
 
-func (level *Level) generateItem(pos Pos, c rune) *Item {
 	switch c {
-	case 's':
-		return NewSword(pos)
-	case 'h':
-		return NewHelmet(pos)
-	case 'a':
-		return NewArmor(pos)
+	case '@':
+		level.Player.Pos = pos
+	case '=':
+		level.AddStorage(NewChest(pos, &StorageConf{items: level.Items[pos], locked: key != "", key: key}))
+		delete(level.Items, pos)
+
 	default:
-		return nil
+		def, exists := level.bestiary.Lookup(c)
+		if !exists {
+			return ErrInvalidRune
+		}
+		level.AddMonster(def.Spawn(pos))
 	}
+	return nil
 }
@@ -0,0 +1,130 @@
+package generator
+
+import (
+	"math/rand"
+	"rpg/game"
+)
+
+func GenerateCave(conf *Conf, player *game.Player) (*Dungeon, error) {
+	if conf.Width < 10 || conf.Height < 5 {
+		return nil, ErrTooSmall
+	}
+	g := &generator{
+		conf:  conf,
+		rng:   rand.New(rand.NewSource(conf.Seed)),
+		level: game.NewLevel(conf.Width, conf.Height, player),
+	}
+
+	walls := g.cellularAutomaton()
+	for y, row := range walls {
+		for x, wall := range row {
+			if wall {
+				g.level.SetTile(game.Pos{X: x, Y: y}, game.OldStoneWall)
+			} else {
+				g.level.SetTile(game.Pos{X: x, Y: y}, game.DirtFloor)
+			}
+		}
+	}
+
+	// entry and exit lie on opposite sides and a tunnel between them guarantees a path
+	dungeon := &Dungeon{
+		Level: g.level,
+		Up:    g.edgeSpot(1),
+		Down:  g.edgeSpot(conf.Width - 1 - conf.Width/5),
+	}
+	g.windingCorridor(dungeon.Up, dungeon.Down)
+	g.level.SetTile(dungeon.Up, game.UpStair)
+	g.level.SetTile(dungeon.Down, game.DownStair)
+
+	// pockets unreachable from the entry are filled in
+	reachable := g.level.Reachable(dungeon.Up)
+	floors := make([]game.Pos, 0, len(reachable))
+	for y, row := range g.level.Map {
+		for x, t := range row {
+			pos := game.Pos{X: x, Y: y}
+			if !isFloor(t) {
+				continue
+			}
+			if reachable[pos] {
+				floors = append(floors, pos)
+			} else {
+				g.level.SetTile(pos, game.OldStoneWall)
+			}
+		}
+	}
+	g.level.ResolveFloors()
+
+	spot := func(far bool) (game.Pos, bool) {
+		for attempt := 0; attempt < 20; attempt++ {
+			pos := floors[g.rng.Intn(len(floors))]
+			if far && distance(pos, dungeon.Up) < 8 {
+				continue
+			}
+			if g.isFree(pos) {
+				return pos, true
+			}
+		}
+		return game.Pos{}, false
+	}
+	// monsters keep away from the entry
+	g.placeEntities(func() (game.Pos, bool) {
+		return spot(true)
+	}, func() (game.Pos, bool) {
+		return spot(false)
+	})
+	return dungeon, nil
+}
+
+func (g *generator) cellularAutomaton() [][]bool {
+	walls := make([][]bool, g.conf.Height)
+	for y := range walls {
+		walls[y] = make([]bool, g.conf.Width)
+		for x := range walls[y] {
+			walls[y][x] = g.isBorder(x, y) || g.rng.Float64() < g.conf.FillChance
+		}
+	}
+
+	for step := 0; step < g.conf.SmoothingSteps; step++ {
+		next := make([][]bool, len(walls))
+		for y := range walls {
+			next[y] = make([]bool, len(walls[y]))
+			for x := range walls[y] {
+				if g.isBorder(x, y) {
+					next[y][x] = true
+					continue
+				}
+				// 4-5 rule, a cell turns into wall with five walls around and stays one with four
+				count := 0
+				for dy := -1; dy <= 1; dy++ {
+					for dx := -1; dx <= 1; dx++ {
+						if (dx != 0 || dy != 0) && walls[y+dy][x+dx] {
+							count++
+						}
+					}
+				}
+				next[y][x] = count >= 5 || (walls[y][x] && count >= 4)
+			}
+		}
+		walls = next
+	}
+	return walls
+}
+
+func (g *generator) isBorder(x, y int) bool {
+	return x == 0 || y == 0 || x == g.conf.Width-1 || y == g.conf.Height-1
+}
+
+func (g *generator) edgeSpot(x int) game.Pos {
+	return game.Pos{X: x + g.rng.Intn(g.conf.Width/5), Y: 1 + g.rng.Intn(g.conf.Height-2)}
+}
+
+func distance(a, b game.Pos) int {
+	dx, dy := a.X-b.X, a.Y-b.Y
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	return dx + dy
+}
@@ -0,0 +1,446 @@
+package generator
+
+import (
+	"errors"
+	"math/rand"
+	"rpg/game"
+)
+
+var (
+	ErrTooSmall     = errors.New("map too small for two rooms")
+	ErrDisconnected = errors.New("generated level is not connected")
+)
+
+type CorridorStyle int
+
+const (
+	CLShaped CorridorStyle = iota
+	CWinding
+)
+
+type Conf struct {
+	Seed          int64
+	Width, Height int
+	Rooms         int
+	MinRoomSize   int
+	MaxRoomSize   int
+	Corridors     CorridorStyle
+	DoorChance    float64
+	PillarChance  float64
+	SleepChance   float64
+	Monsters      int
+	Items         int
+	Chests        int
+	// monsters and items are picked from these definitions, without them none are placed
+	Bestiary *game.Bestiary
+	ItemDefs *game.ItemRegistry
+
+	// caves only
+	FillChance     float64
+	SmoothingSteps int
+}
+
+func DefaultConf() *Conf {
+	return &Conf{
+		Width:        60,
+		Height:       40,
+		Rooms:        8,
+		MinRoomSize:  4,
+		MaxRoomSize:  10,
+		Corridors:    CLShaped,
+		DoorChance:   0.7,
+		PillarChance: 0.3,
+		SleepChance:  0.5,
+		Monsters:     6,
+		Items:        3,
+		Chests:       2,
+
+		FillChance:     0.45,
+		SmoothingSteps: 4,
+	}
+}
+
+type Room struct {
+	X, Y, W, H int
+}
+
+func (room Room) Center() game.Pos {
+	return game.Pos{X: room.X + room.W/2, Y: room.Y + room.H/2}
+}
+
+type Dungeon struct {
+	Level *game.Level
+	Rooms []Room
+	Up    game.Pos
+	Down  game.Pos
+}
+
+type generator struct {
+	conf  *Conf
+	rng   *rand.Rand
+	level *game.Level
+	rooms []Room
+}
+
+func Generate(conf *Conf, player *game.Player) (*Dungeon, error) {
+	g := &generator{
+		conf:  conf,
+		rng:   rand.New(rand.NewSource(conf.Seed)),
+		level: game.NewLevel(conf.Width, conf.Height, player),
+	}
+	for y := 0; y < conf.Height; y++ {
+		for x := 0; x < conf.Width; x++ {
+			g.level.SetTile(game.Pos{X: x, Y: y}, game.StoneWall)
+		}
+	}
+
+	root := g.partition()
+	g.placeRooms(root)
+	if len(g.rooms) < 2 {
+		return nil, ErrTooSmall
+	}
+	g.connect(root)
+	g.placeDoors()
+
+	dungeon := &Dungeon{
+		Level: g.level,
+		Rooms: g.rooms,
+		Up:    g.rooms[0].Center(),
+		Down:  g.rooms[len(g.rooms)-1].Center(),
+	}
+	g.level.SetTile(dungeon.Up, game.UpStair)
+	g.level.SetTile(dungeon.Down, game.DownStair)
+	g.placePillars(dungeon.Up)
+	g.level.ResolveFloors()
+	// monsters keep away from the first room with the up stairs
+	g.placeEntities(func() (game.Pos, bool) {
+		return g.freeSpot(g.rooms[1+g.rng.Intn(len(g.rooms)-1)])
+	}, func() (game.Pos, bool) {
+		return g.freeSpot(g.rooms[g.rng.Intn(len(g.rooms))])
+	})
+
+	reachable := g.level.Reachable(dungeon.Up)
+	if !reachable[dungeon.Down] {
+		return nil, ErrDisconnected
+	}
+	for y, row := range g.level.Map {
+		for x, t := range row {
+			if isFloor(t) && t.OverlayRune != game.StonePillar && !reachable[game.Pos{X: x, Y: y}] {
+				return nil, ErrDisconnected
+			}
+		}
+	}
+	return dungeon, nil
+}
+
+type node struct {
+	x, y, w, h  int
+	left, right *node
+	room        *Room
+}
+
+// binary space partitioning, the largest leaf is split until there is a leaf per room
+func (g *generator) partition() *node {
+	minLeaf := g.conf.MinRoomSize + 2
+	root := &node{x: 0, y: 0, w: g.conf.Width, h: g.conf.Height}
+	leaves := []*node{root}
+	for len(leaves) < g.conf.Rooms {
+		best := -1
+		for i, leaf := range leaves {
+			if leaf.w < 2*minLeaf && leaf.h < 2*minLeaf {
+				continue
+			}
+			if best < 0 || leaf.w*leaf.h > leaves[best].w*leaves[best].h {
+				best = i
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		leaf := leaves[best]
+		vertical := leaf.w >= leaf.h
+		if leaf.w < 2*minLeaf {
+			vertical = false
+		} else if leaf.h < 2*minLeaf {
+			vertical = true
+		}
+		if vertical {
+			split := minLeaf + g.rng.Intn(leaf.w-2*minLeaf+1)
+			leaf.left = &node{x: leaf.x, y: leaf.y, w: split, h: leaf.h}
+			leaf.right = &node{x: leaf.x + split, y: leaf.y, w: leaf.w - split, h: leaf.h}
+		} else {
+			split := minLeaf + g.rng.Intn(leaf.h-2*minLeaf+1)
+			leaf.left = &node{x: leaf.x, y: leaf.y, w: leaf.w, h: split}
+			leaf.right = &node{x: leaf.x, y: leaf.y + split, w: leaf.w, h: leaf.h - split}
+		}
+		leaves = append(leaves[:best], append([]*node{leaf.left, leaf.right}, leaves[best+1:]...)...)
+	}
+	return root
+}
+
+func (g *generator) placeRooms(n *node) {
+	if n.left != nil {
+		g.placeRooms(n.left)
+		g.placeRooms(n.right)
+		return
+	}
+	if n.w < g.conf.MinRoomSize+2 || n.h < g.conf.MinRoomSize+2 {
+		return
+	}
+
+	w := g.roomSize(n.w - 2)
+	h := g.roomSize(n.h - 2)
+	room := Room{
+		X: n.x + 1 + g.rng.Intn(n.w-w-1),
+		Y: n.y + 1 + g.rng.Intn(n.h-h-1),
+		W: w,
+		H: h,
+	}
+	for y := room.Y; y < room.Y+room.H; y++ {
+		for x := room.X; x < room.X+room.W; x++ {
+			g.level.SetTile(game.Pos{X: x, Y: y}, game.StoneFloor)
+		}
+	}
+	n.room = &room
+	g.rooms = append(g.rooms, room)
+}
+
+func (g *generator) roomSize(space int) int {
+	max := g.conf.MaxRoomSize
+	if max > space {
+		max = space
+	}
+	if max <= g.conf.MinRoomSize {
+		return max
+	}
+	return g.conf.MinRoomSize + g.rng.Intn(max-g.conf.MinRoomSize+1)
+}
+
+func (n *node) pickRoom(rng *rand.Rand) *Room {
+	if n.left == nil {
+		return n.room
+	}
+	first, second := n.left, n.right
+	if rng.Intn(2) == 0 {
+		first, second = second, first
+	}
+	if room := first.pickRoom(rng); room != nil {
+		return room
+	}
+	return second.pickRoom(rng)
+}
+
+// sibling subtrees get joined by a corridor, which connects the whole tree
+func (g *generator) connect(n *node) {
+	if n.left == nil {
+		return
+	}
+	g.connect(n.left)
+	g.connect(n.right)
+
+	from := n.left.pickRoom(g.rng)
+	to := n.right.pickRoom(g.rng)
+	if from == nil || to == nil {
+		return
+	}
+	switch g.conf.Corridors {
+	case CWinding:
+		g.windingCorridor(from.Center(), to.Center())
+	default:
+		g.lShapedCorridor(from.Center(), to.Center())
+	}
+}
+
+func (g *generator) carve(pos game.Pos) {
+	if !isFloor(g.level.Map[pos.Y][pos.X]) {
+		g.level.SetTile(pos, game.DirtFloor)
+	}
+}
+
+func (g *generator) lShapedCorridor(from, to game.Pos) {
+	corner := game.Pos{X: to.X, Y: from.Y}
+	if g.rng.Intn(2) == 0 {
+		corner = game.Pos{X: from.X, Y: to.Y}
+	}
+	g.straightCorridor(from, corner)
+	g.straightCorridor(corner, to)
+}
+
+func (g *generator) straightCorridor(from, to game.Pos) {
+	g.carve(from)
+	for from != to {
+		from.X += sign(to.X - from.X)
+		from.Y += sign(to.Y - from.Y)
+		g.carve(from)
+	}
+}
+
+func (g *generator) windingCorridor(from, to game.Pos) {
+	g.carve(from)
+	for from != to {
+		dx, dy := sign(to.X-from.X), sign(to.Y-from.Y)
+		switch {
+		case g.rng.Float64() < 0.3:
+			// wander sideways, staying off the map border
+			if dx != 0 {
+				dx, dy = 0, 1-2*g.rng.Intn(2)
+			} else {
+				dx, dy = 1-2*g.rng.Intn(2), 0
+			}
+		case dx != 0 && dy != 0:
+			if g.rng.Intn(2) == 0 {
+				dx = 0
+			} else {
+				dy = 0
+			}
+		}
+		next := game.Pos{X: from.X + dx, Y: from.Y + dy}
+		if next.X < 1 || next.Y < 1 || next.X > g.conf.Width-2 || next.Y > g.conf.Height-2 {
+			continue
+		}
+		from = next
+		g.carve(from)
+	}
+}
+
+// corridors entering a room through a gap in its wall get a door
+func (g *generator) placeDoors() {
+	for _, room := range g.rooms {
+		for x := room.X; x < room.X+room.W; x++ {
+			g.placeDoor(game.Pos{X: x, Y: room.Y - 1}, true)
+			g.placeDoor(game.Pos{X: x, Y: room.Y + room.H}, true)
+		}
+		for y := room.Y; y < room.Y+room.H; y++ {
+			g.placeDoor(game.Pos{X: room.X - 1, Y: y}, false)
+			g.placeDoor(game.Pos{X: room.X + room.W, Y: y}, false)
+		}
+	}
+}
+
+func (g *generator) placeDoor(pos game.Pos, horizontalWall bool) {
+	if !isFloor(g.level.Map[pos.Y][pos.X]) {
+		return
+	}
+	var side1, side2 game.Pos
+	if horizontalWall {
+		side1, side2 = game.Pos{X: pos.X - 1, Y: pos.Y}, game.Pos{X: pos.X + 1, Y: pos.Y}
+	} else {
+		side1, side2 = game.Pos{X: pos.X, Y: pos.Y - 1}, game.Pos{X: pos.X, Y: pos.Y + 1}
+	}
+	if !g.isWall(side1) || !g.isWall(side2) || g.rng.Float64() >= g.conf.DoorChance {
+		return
+	}
+	if g.rng.Intn(2) == 0 {
+		g.level.SetTile(pos, game.ClosedDoor)
+	} else {
+		g.level.SetTile(pos, game.OpenedDoor)
+	}
+}
+
+// pillars go to the inner corners of bigger rooms unless they would cut a passage
+func (g *generator) placePillars(start game.Pos) {
+	walkable := len(g.level.Reachable(start))
+	for _, room := range g.rooms {
+		if room.W < 5 || room.H < 5 || g.rng.Float64() >= g.conf.PillarChance {
+			continue
+		}
+		corners := []game.Pos{
+			{X: room.X + 1, Y: room.Y + 1},
+			{X: room.X + room.W - 2, Y: room.Y + 1},
+			{X: room.X + 1, Y: room.Y + room.H - 2},
+			{X: room.X + room.W - 2, Y: room.Y + room.H - 2},
+		}
+		for _, pos := range corners {
+			t := g.level.Map[pos.Y][pos.X]
+			if t.Rune != game.StoneFloor || t.OverlayRune != game.Blank {
+				continue
+			}
+			g.level.SetTile(pos, game.StonePillar)
+			if reachable := len(g.level.Reachable(start)); reachable == walkable-1 {
+				walkable = reachable
+			} else {
+				g.level.SetTile(pos, game.StoneFloor)
+			}
+		}
+	}
+}
+
+func (g *generator) placeEntities(monsterSpot, spot func() (game.Pos, bool)) {
+	var monsters []*game.MonsterDef
+	if g.conf.Bestiary != nil {
+		monsters = g.conf.Bestiary.Defs()
+	}
+	for i := 0; i < g.conf.Monsters && len(monsters) > 0; i++ {
+		if pos, ok := monsterSpot(); ok {
+			monster := monsters[g.rng.Intn(len(monsters))].Spawn(pos)
+			if g.rng.Float64() < g.conf.SleepChance {
+				monster.Awareness = game.Asleep
+			}
+			g.level.AddMonster(monster)
+		}
+	}
+
+	// keys are left out as there are no locks to open
+	var items []*game.ItemDef
+	if g.conf.ItemDefs != nil {
+		for _, def := range g.conf.ItemDefs.Defs() {
+			if def.Slot != game.Key {
+				items = append(items, def)
+			}
+		}
+	}
+	for i := 0; i < g.conf.Items && len(items) > 0; i++ {
+		if pos, ok := spot(); ok {
+			g.level.AddItem(items[g.rng.Intn(len(items))].Spawn(pos))
+		}
+	}
+	for i := 0; i < g.conf.Chests; i++ {
+		if pos, ok := spot(); ok {
+			chest := game.NewChest(pos, &game.StorageConf{})
+			for n := g.rng.Intn(3); n > 0 && len(items) > 0; n-- {
+				chest.Items = append(chest.Items, items[g.rng.Intn(len(items))].Spawn(pos))
+			}
+			g.level.AddStorage(chest)
+		}
+	}
+}
+
+func (g *generator) freeSpot(room Room) (game.Pos, bool) {
+	for attempt := 0; attempt < 20; attempt++ {
+		pos := game.Pos{X: room.X + g.rng.Intn(room.W), Y: room.Y + g.rng.Intn(room.H)}
+		if g.isFree(pos) {
+			return pos, true
+		}
+	}
+	return game.Pos{}, false
+}
+
+func (g *generator) isFree(pos game.Pos) bool {
+	t := g.level.Map[pos.Y][pos.X]
+	if !isFloor(t) || t.OverlayRune != game.Blank {
+		return false
+	}
+	_, monster := g.level.AliveMonstersPos[pos]
+	_, storage := g.level.Storages[pos]
+	return !monster && !storage && len(g.level.Items[pos]) == 0
+}
+
+func (g *generator) isWall(pos game.Pos) bool {
+	t := g.level.Map[pos.Y][pos.X]
+	return t.Rune == game.StoneWall && t.OverlayRune == game.Blank
+}
+
+func isFloor(t game.Tile) bool {
+	return t.Rune == game.StoneFloor || t.Rune == game.DirtFloor || t.Rune == game.Pending
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,70 @@
+package game
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+type setter func(key, value string) error
+
+// readINI passes key value pairs to the setter opened for their section, keys
+// before the first section get a setter opened with no header, # starts a comment
+func readINI(filename string, open func(header []string, line int) setter) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var errs ErrorList
+	var set setter
+	inSection := false
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lineError := func(column int, err error) *LoadError {
+			return &LoadError{File: filename, Line: lineNumber, Column: column, Err: err}
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			header := strings.Fields(line[1 : len(line)-1])
+			inSection = true
+			set = nil
+			if len(header) > 0 {
+				set = open(header, lineNumber)
+			}
+			if set == nil {
+				errs.add(lineError(2, ErrUnknownField))
+			}
+			continue
+		}
+
+		separator := strings.Index(line, "=")
+		if separator < 0 {
+			errs.add(lineError(len(line)+1, ErrMissingField))
+			continue
+		}
+		key := strings.TrimSpace(line[:separator])
+		value := strings.TrimSpace(line[separator+1:])
+		if !inSection {
+			set = open(nil, lineNumber)
+		}
+		// keys of an unknown section were already reported with its header
+		if set == nil {
+			continue
+		}
+		if err := set(key, value); err != nil {
+			errs.add(lineError(separator+2, err))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return errs.err()
+}
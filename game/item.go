@@ -1,5 +1,7 @@
 package game
 
+import "strconv"
+
 type ItemType int
 
 const (
@@ -7,25 +9,74 @@ const (
 	Helmet
 	Armor
 	Other
+	Key
+	Consumable
 )
 
+var itemSlots = map[string]ItemType{
+	"weapon":     Weapon,
+	"helmet":     Helmet,
+	"armor":      Armor,
+	"other":      Other,
+	"key":        Key,
+	"consumable": Consumable,
+}
+
 type Item struct {
 	Entity
-	Typ   ItemType
-	Power float64
+	ID       int
+	Typ      ItemType
+	KeyID    string
+	Quantity int
+
+	kind *ItemDef
+}
+
+func (item *Item) stacksWith(other *Item) bool {
+	return item.kind == other.kind && item.kind.Stackable && item.KeyID == other.KeyID
+}
+
+// entityField is the optional fourth field of the item in a map file
+func (item *Item) entityField() string {
+	if item.kind.Stackable && item.Quantity > 1 {
+		return strconv.Itoa(item.Quantity)
+	}
+	return item.KeyID
 }
 
-func NewSword(p Pos) *Item {
-	item := &Item{Entity{p, 's', "Sword"}, Weapon, 2.0}
-	return item
+// stack merges the item into a matching stack of the list or appends it
+func stack(items []*Item, item *Item) []*Item {
+	for _, other := range items {
+		if other != item && other.stacksWith(item) {
+			other.Quantity += item.Quantity
+			return items
+		}
+	}
+	return append(items, item)
 }
 
-func NewHelmet(p Pos) *Item {
-	item := &Item{Entity{p, 'h', "Helmet"}, Helmet, 0.1}
-	return item
+func holds(items []*Item, item *Item) bool {
+	for _, other := range items {
+		if other == item {
+			return true
+		}
+	}
+	return false
 }
 
-func NewArmor(p Pos) *Item {
-	item := &Item{Entity{p, 'a', "Armor"}, Armor, 0.2}
-	return item
+// split takes quantity off the stack as a new item placed next to it in the list,
+// quantities outside of the stack leave it whole
+func split(items []*Item, item *Item, quantity int) ([]*Item, *Item) {
+	if quantity <= 0 || quantity >= item.Quantity {
+		return items, item
+	}
+	for _, other := range items {
+		if other == item {
+			piece := item.kind.Spawn(item.Pos)
+			piece.KeyID, piece.Quantity = item.KeyID, quantity
+			item.Quantity -= quantity
+			return append(items, piece), piece
+		}
+	}
+	return items, item
 }
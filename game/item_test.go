@@ -0,0 +1,59 @@
+package game
+
+import "testing"
+
+// newGoldGame starts the shipped dungeon with the player standing on the pile of gold
+func newGoldGame(t *testing.T) (*Game, *Item) {
+	game, err := NewGame(testConf())
+	if err != nil {
+		t.Fatal(err)
+	}
+	game.Start()
+	game.Step(&Input{Typ: IMove, Direction: DDown})
+	return game, game.CurrentLevel.Items[game.Player.Pos][0]
+}
+
+func TestSplitStack(t *testing.T) {
+	game, gold := newGoldGame(t)
+	total := gold.Quantity
+	ground := game.CurrentLevel.Items[game.Player.Pos]
+	ground, piece := split(ground, gold, 10)
+	if piece == gold {
+		t.Fatal("stack was not split")
+	}
+	if piece.Quantity != 10 || gold.Quantity != total-10 {
+		t.Errorf("split %d into %d and %d", total, piece.Quantity, gold.Quantity)
+	}
+	game.CurrentLevel.Items[game.Player.Pos] = ground
+	// the ui targets the piece right away, before any other view is built
+	if piece.ID == 0 || piece.ID == gold.ID {
+		t.Fatalf("piece has id %d, stack %d", piece.ID, gold.ID)
+	}
+	if game.findItem(piece.ID) != piece {
+		t.Errorf("piece #%d cannot be found", piece.ID)
+	}
+
+	if _, whole := split(ground, gold, 0); whole != gold {
+		t.Error("zero quantity split the stack")
+	}
+}
+
+func TestFailedPartialMoves(t *testing.T) {
+	game, gold := newGoldGame(t)
+	game.Step(&Input{Typ: ITakeItem, ItemID: gold.ID})
+	if len(game.Player.Items) != 1 {
+		t.Fatal("gold was not taken")
+	}
+	total := gold.Quantity
+
+	// there is no chest here to drop or store into
+	for _, typ := range []InputType{IDropItem, IStoreItem, ITakeItem, IWithdrawItem} {
+		game.Step(&Input{Typ: typ, ItemID: gold.ID, Quantity: 5})
+		if len(game.Player.Items) != 1 || gold.Quantity != total {
+			t.Errorf("input %d left %d stacks with %d gold, want 1 with %d", typ, len(game.Player.Items), gold.Quantity, total)
+		}
+		if len(game.CurrentLevel.Items[game.Player.Pos]) != 0 {
+			t.Errorf("input %d left gold on the ground", typ)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package game
+
+import (
+	"math/rand"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const itemsFile = "items.ini"
+
+type ItemDef struct {
+	Name      string
+	Rune      rune
+	Sprite    Sprite
+	Slot      ItemType
+	MinDamage int
+	MaxDamage int
+	Armor     int
+	Weight    float64
+	Value     int
+	Light     int
+	Stackable bool
+	Effect    Effect
+	// hitpoints healed at once or turns of regeneration
+	Amount      int
+	Description string
+
+	registry *ItemRegistry
+	line     int
+}
+
+type ItemRegistry struct {
+	index defIndex
+	// lastID numbers the spawned items so inputs and replays can refer to them
+	lastID int
+}
+
+func LoadItemRegistry(filename string) (*ItemRegistry, error) {
+	registry := &ItemRegistry{index: newDefIndex()}
+	// sections are checked for missing fields only after the whole file is read
+	var sections []*ItemDef
+	var errs ErrorList
+	errs.add(readINI(filename, func(header []string, line int) setter {
+		if len(header) < 2 || header[0] != "item" {
+			return nil
+		}
+		def := &ItemDef{Name: strings.Join(header[1:], " "), Slot: Other, registry: registry, line: line}
+		sections = append(sections, def)
+		return registry.define(def)
+	}))
+	for _, def := range sections {
+		// the ui has nothing to draw for an item without a sprite
+		if def.Rune == 0 || def.Sprite.Count == 0 {
+			errs.add(&LoadError{File: filename, Line: def.line, Err: ErrMissingField})
+		}
+	}
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// define registers the item once its rune is known and returns the setter of its fields
+func (registry *ItemRegistry) define(def *ItemDef) setter {
+	return func(key, value string) error {
+		var err error
+		switch key {
+		case "rune":
+			r, err := registry.index.parseRune(value)
+			if err != nil {
+				return err
+			}
+			def.Rune = r
+			registry.index.add(def)
+		case "sprite":
+			def.Sprite, err = parseSprite(value)
+		case "slot":
+			slot, exists := itemSlots[value]
+			if !exists {
+				return ErrUnknownField
+			}
+			def.Slot = slot
+		case "damage":
+			def.MinDamage, def.MaxDamage, err = parseRange(value)
+		case "armor":
+			def.Armor, err = parseInt(value)
+		case "weight":
+			def.Weight, err = parseFloat(value)
+		case "value":
+			def.Value, err = parseInt(value)
+		case "light":
+			def.Light, err = parseInt(value)
+		case "stackable":
+			if def.Stackable, err = strconv.ParseBool(value); err != nil {
+				return ErrInvalidNumber
+			}
+		case "effect":
+			effect, exists := effects[value]
+			if !exists {
+				return ErrUnknownField
+			}
+			def.Effect = effect
+		case "amount":
+			def.Amount, err = parseInt(value)
+		case "description":
+			def.Description = value
+		default:
+			return ErrUnknownField
+		}
+		return err
+	}
+}
+
+// LoadDefinitions reads the item and monster definitions of a data directory
+func LoadDefinitions(dir string) (*ItemRegistry, *Bestiary, error) {
+	itemDefs, err := LoadItemRegistry(filepath.Join(dir, itemsFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	bestiary, err := LoadBestiary(filepath.Join(dir, monstersFile), itemDefs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return itemDefs, bestiary, nil
+}
+
+func (registry *ItemRegistry) Lookup(r rune) (*ItemDef, bool) {
+	def, exists := registry.index.runes[r]
+	if !exists {
+		return nil, false
+	}
+	return def.(*ItemDef), true
+}
+
+// Named finds the definition a saved item is spawned from again
+func (registry *ItemRegistry) Named(name string) (*ItemDef, bool) {
+	def, exists := registry.index.names[name]
+	if !exists {
+		return nil, false
+	}
+	return def.(*ItemDef), true
+}
+
+// Defs lists the items ordered by rune, the generator draws the loot of chests from it
+func (registry *ItemRegistry) Defs() []*ItemDef {
+	defs := make([]*ItemDef, len(registry.index.sorted))
+	for i, def := range registry.index.sorted {
+		defs[i] = def.(*ItemDef)
+	}
+	return defs
+}
+
+func (def *ItemDef) Spawn(pos Pos) *Item {
+	def.registry.lastID++
+	return &Item{Entity: Entity{pos, def.Rune, def.Name}, ID: def.registry.lastID, Typ: def.Slot, Quantity: 1, kind: def}
+}
+
+func (def *ItemDef) rollDamage(rng *rand.Rand) int {
+	if def.MaxDamage <= def.MinDamage {
+		return def.MinDamage
+	}
+	return def.MinDamage + rng.Intn(def.MaxDamage-def.MinDamage+1)
+}
+
+// damage ranges are written as "min-max" or a single number
+func parseRange(value string) (int, int, error) {
+	bounds := strings.Split(value, "-")
+	if len(bounds) > 2 {
+		return 0, 0, ErrInvalidNumber
+	}
+	min, err := parseInt(bounds[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	max := min
+	if len(bounds) == 2 {
+		if max, err = parseInt(bounds[1]); err != nil {
+			return 0, 0, err
+		}
+	}
+	if max < min {
+		return 0, 0, ErrInvalidNumber
+	}
+	return min, max, nil
+}
@@ -0,0 +1,47 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+const testItems = `[item Sword]
+rune = s
+sprite = 3,46,1
+`
+
+func loadTestDefinitions(t *testing.T, items, monsters string) error {
+	dir := writeFixtures(t, map[string]string{itemsFile: items, monstersFile: monsters})
+	_, _, err := LoadDefinitions(dir)
+	return err
+}
+
+func TestLoadDefinitions(t *testing.T) {
+	tests := []struct {
+		name     string
+		items    string
+		monsters string
+		want     string
+	}{
+		{"valid", testItems, "[monster Rat]\nrune = R\nsprite = 28,64,1\n", ""},
+		{"item without sprite", "[item Sword]\nrune = s\n", "", "items.ini:1: missing field"},
+		{"monster without sprite", testItems, "[monster Rat]\nrune = R\n", "monsters.ini:1: missing field"},
+		// reported in file order, the first error is followed by the second one
+		{"missing in order", "[item Sword]\nrune = s\n[item Helmet]\nrune = h\n", "", "items.ini:1: missing field\n"},
+		{"empty sprite", "[item Sword]\nrune = s\nsprite = 3,46,0\n", "", "items.ini:3"},
+		{"tile rune", "[item Sword]\nrune = #\nsprite = 3,46,1\n", "", "invalid rune"},
+		{"player rune", testItems, "[monster Rat]\nrune = @\nsprite = 28,64,1\n", "invalid rune"},
+		{"item rune", testItems, "[monster Snake]\nrune = s\nsprite = 28,64,1\n", "monsters.ini:2"},
+	}
+	for _, test := range tests {
+		err := loadTestDefinitions(t, test.items, test.monsters)
+		switch {
+		case test.want == "" && err != nil:
+			t.Errorf("%s: %v", test.name, err)
+		case test.want != "" && err == nil:
+			t.Errorf("%s: no error, want %q", test.name, test.want)
+		case test.want != "" && !strings.Contains(err.Error(), test.want):
+			t.Errorf("%s: %v, want %q", test.name, err, test.want)
+		}
+	}
+}
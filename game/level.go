@@ -2,50 +2,72 @@ package game
 
 import (
 	"bufio"
+	"fmt"
+	"io"
 	"math"
+	"math/rand"
 	"os"
 	"strconv"
 	"strings"
 )
 
 type Level struct {
+	Name     string
+	Depth    int
+	Music    string
+	Ambient  float64
 	Map      [][]Tile
 	Player   *Player
 	Monsters []*Monster
 
 	AliveMonstersPos map[Pos]*Monster
 	Items            map[Pos][]*Item
-	Portals          map[Pos]*LevelPos
+	Portals          map[Pos]*Passage
 	Storages         map[Pos]*Storage
+	Locks            map[Pos]string
 
 	Log        []string
 	Debug      map[Pos]bool
-	LastEvents []GameEvent
+	LastEvents []Event
+
+	rng      *rand.Rand
+	bestiary *Bestiary
+	itemDefs *ItemRegistry
+	visible  []Pos
+	approach DistanceMap
+	flee     DistanceMap
+	diagonal bool
 }
 
-type GameEvent int
-
-const (
-	Move GameEvent = iota
-	DoorOpen
-	DoorClose
-	Attack
-	Portal
-	PickUp
-	DropDown
-	Equip
-	TakeOff
-)
-
 type LevelPos struct {
 	level *Level
 	pos   Pos
 }
 
-func NewLevelFromFile(filename string, player *Player) *Level {
+type Passage struct {
+	LevelPos
+	Name  string
+	KeyID string
+}
+
+func newLevel(player *Player) *Level {
+	level := &Level{}
+	level.Depth = 1
+	level.Ambient = 1
+	level.Player = player
+	level.AliveMonstersPos = make(map[Pos]*Monster)
+	level.Portals = make(map[Pos]*Passage)
+	level.Storages = make(map[Pos]*Storage)
+	level.Locks = make(map[Pos]string)
+	level.Items = make(map[Pos][]*Item)
+	level.Debug = make(map[Pos]bool)
+	return level
+}
+
+func NewLevelFromFile(filename string, player *Player, bestiary *Bestiary, itemDefs *ItemRegistry) (*Level, error) {
 	file, err := os.Open(filename)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	defer file.Close()
 
@@ -72,54 +94,233 @@ func NewLevelFromFile(filename string, player *Player) *Level {
 			index++
 		}
 	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(levelLines) == 0 || longestRow == 0 {
+		return nil, &LoadError{File: filename, Err: ErrEmptyMap}
+	}
 
-	level := &Level{}
+	level := newLevel(player)
+	level.bestiary = bestiary
+	level.itemDefs = itemDefs
 	level.Map = make([][]Tile, len(levelLines))
 	for i := range level.Map {
 		level.Map[i] = make([]Tile, longestRow)
 	}
-	level.Player = player
-	level.AliveMonstersPos = make(map[Pos]*Monster)
-	level.Portals = make(map[Pos]*LevelPos)
-	level.Storages = make(map[Pos]*Storage)
-	level.Items = make(map[Pos][]*Item)
-	level.Debug = make(map[Pos]bool)
 
+	var errs ErrorList
 	for y := range level.Map {
 		line := levelLines[y]
 		for x, c := range line {
-			level.generateTile(x, y, c)
+			if err := level.generateTile(x, y, c); err != nil {
+				errs.add(&LoadError{File: filename, Line: y + 1, Column: x + 1, Rune: c, Err: err})
+			}
 		}
 	}
 
-	for y, row := range level.Map {
-		for x, tile := range row {
-			if tile.Rune == Pending {
-				level.Map[y][x].Rune = level.BfsFloor(Pos{x, y})
-			}
+	level.ResolveFloors()
+
+	// entities are listed after the map and the ENTITIES: separator
+	firstEntityLine := len(levelLines) + 2
+	for i, line := range entityLines {
+		lineNumber := firstEntityLine + i
+		if strings.TrimSpace(line) == "" {
+			continue
 		}
-	}
 
-	for _, line := range entityLines {
 		splitCXY := strings.Split(line, ",")
 		if len(splitCXY) < 3 {
+			errs.add(&LoadError{File: filename, Line: lineNumber, Column: len(line) + 1, Err: ErrMissingField})
+			continue
+		}
+		if len(splitCXY[0]) != 1 {
+			errs.add(&LoadError{File: filename, Line: lineNumber, Column: 1, Err: ErrInvalidRune})
 			continue
 		}
 		c := rune(splitCXY[0][0])
 		x, err := strconv.Atoi(splitCXY[1])
 		if err != nil {
-			panic(err)
+			errs.add(&LoadError{File: filename, Line: lineNumber, Column: len(splitCXY[0]) + 2, Err: ErrInvalidNumber})
+			continue
 		}
 		y, err := strconv.Atoi(splitCXY[2])
 		if err != nil {
-			panic(err)
+			errs.add(&LoadError{File: filename, Line: lineNumber, Column: len(splitCXY[0]) + len(splitCXY[1]) + 3, Err: ErrInvalidNumber})
+			continue
+		}
+		if !level.inRange(Pos{x, y}) {
+			errs.add(&LoadError{File: filename, Line: lineNumber, Column: 1, Rune: c, Err: ErrOutOfBounds})
+			continue
+		}
+		key := ""
+		if len(splitCXY) > 3 {
+			key = strings.TrimSpace(splitCXY[3])
+		}
+		if err := level.generateEntity(x, y, c, key); err != nil {
+			errs.add(&LoadError{File: filename, Line: lineNumber, Column: 1, Rune: c, Err: err})
 		}
-		level.generateEntity(x, y, c)
 	}
 
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	return level, nil
+}
+
+func NewLevel(width, height int, player *Player) *Level {
+	level := newLevel(player)
+	level.Map = make([][]Tile, height)
+	for y := range level.Map {
+		level.Map[y] = make([]Tile, width)
+		for x := range level.Map[y] {
+			level.generateTile(x, y, ' ')
+		}
+	}
 	return level
 }
 
+func (level *Level) SetTile(pos Pos, c rune) error {
+	if !level.inRange(pos) {
+		return ErrOutOfBounds
+	}
+	return level.generateTile(pos.X, pos.Y, c)
+}
+
+// tiles under doors, stairs and entities take the nearest floor
+func (level *Level) ResolveFloors() {
+	for y, row := range level.Map {
+		for x, tile := range row {
+			if tile.Rune == Pending {
+				level.Map[y][x].Rune = level.BfsFloor(Pos{x, y})
+			}
+		}
+	}
+}
+
+func (level *Level) AddMonster(m *Monster) {
+	level.Monsters = append(level.Monsters, m)
+	level.AliveMonstersPos[m.Pos] = m
+}
+
+func (level *Level) AddItem(item *Item) {
+	level.Items[item.Pos] = stack(level.Items[item.Pos], item)
+}
+
+func (level *Level) AddStorage(storage *Storage) {
+	level.Storages[storage.Pos] = storage
+}
+
+func (level *Level) Link(pos Pos, dstLevel *Level, dstPos Pos) {
+	level.Portals[pos] = &Passage{LevelPos: LevelPos{dstLevel, dstPos}}
+}
+
+func (level *Level) stairs(overlay rune) []Pos {
+	positions := make([]Pos, 0, 1)
+	for y, row := range level.Map {
+		for x, t := range row {
+			if t.OverlayRune == overlay {
+				positions = append(positions, Pos{x, y})
+			}
+		}
+	}
+	return positions
+}
+
+// LinkStairs pairs down stairs of the upper floor with up stairs of the lower one in reading order
+func LinkStairs(upper, lower *Level) error {
+	downs, ups := upper.stairs(DownStair), lower.stairs(UpStair)
+	if len(downs) != len(ups) {
+		return ErrStairMismatch
+	}
+	for i := range downs {
+		_, downLinked := upper.Portals[downs[i]]
+		_, upLinked := lower.Portals[ups[i]]
+		if downLinked || upLinked {
+			return ErrDuplicatePortal
+		}
+	}
+	for i := range downs {
+		upper.Link(downs[i], lower, ups[i])
+		lower.Link(ups[i], upper, downs[i])
+	}
+	return nil
+}
+
+func (level *Level) Reachable(start Pos) map[Pos]bool {
+	visited := map[Pos]bool{start: true}
+	frontier := []Pos{start}
+	for len(frontier) > 0 {
+		current := frontier[0]
+		frontier = frontier[1:]
+		for _, next := range level.neighbors(current, level.canPass) {
+			if !visited[next] {
+				visited[next] = true
+				frontier = append(frontier, next)
+			}
+		}
+	}
+	return visited
+}
+
+func (level *Level) WriteMap(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+	for _, row := range level.Map {
+		for _, t := range row {
+			switch {
+			case t.OverlayRune != Blank:
+				buf.WriteRune(t.OverlayRune)
+			case t.Rune == Blank || t.Rune == Pending:
+				buf.WriteRune(' ')
+			default:
+				buf.WriteRune(t.Rune)
+			}
+		}
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("ENTITIES:\n")
+	writeEntity := func(c rune, pos Pos, key string) {
+		if key != "" {
+			fmt.Fprintf(buf, "%c,%d,%d,%s\n", c, pos.X, pos.Y, key)
+		} else {
+			fmt.Fprintf(buf, "%c,%d,%d\n", c, pos.X, pos.Y)
+		}
+	}
+	for _, monster := range level.Monsters {
+		writeEntity(monster.Rune, monster.Pos, "")
+	}
+	positions := make([]Pos, 0, len(level.Items))
+	for pos := range level.Items {
+		positions = append(positions, pos)
+	}
+	for _, pos := range sortPositions(positions) {
+		for _, item := range level.Items[pos] {
+			writeEntity(item.Rune, pos, item.entityField())
+		}
+	}
+	// chest contents are listed right before the chest collecting them
+	positions = positions[:0]
+	for pos := range level.Storages {
+		positions = append(positions, pos)
+	}
+	for _, pos := range sortPositions(positions) {
+		storage := level.Storages[pos]
+		for _, item := range storage.Items {
+			writeEntity(item.Rune, pos, item.entityField())
+		}
+		writeEntity(storage.Rune, pos, storage.KeyID)
+	}
+	positions = positions[:0]
+	for pos := range level.Locks {
+		positions = append(positions, pos)
+	}
+	for _, pos := range sortPositions(positions) {
+		writeEntity(ClosedDoor, pos, level.Locks[pos])
+	}
+	return buf.Flush()
+}
+
 func (level *Level) inRange(pos Pos) bool {
 	return pos.X < len(level.Map[0]) && pos.Y < len(level.Map) && pos.X >= 0 && pos.Y >= 0
 }
@@ -140,73 +341,19 @@ func (level *Level) canSeeThrough(pos Pos) bool {
 	return false
 }
 
-func (level *Level) bresenhamVisibility(start Pos, end Pos) {
-	steep := math.Abs(float64(end.Y-start.Y)) > math.Abs(float64(end.X-start.X))
-	if steep {
-		start.X, start.Y = start.Y, start.X
-		end.X, end.Y = end.Y, end.X
-	}
-
-	deltaX := int(math.Abs(float64(end.X - start.X)))
-	deltaY := int(math.Abs(float64(end.Y - start.Y)))
-	err := 0
-	y := start.Y
-	ystep := 1
-	if start.Y >= end.Y {
-		ystep = -1
-	}
-
-	reversed := start.X > end.X
-	if reversed {
-		for x := start.X; x > end.X; x-- {
-			var pos Pos
-			if steep {
-				pos = Pos{y, x}
-			} else {
-				pos = Pos{x, y}
-			}
-			level.Map[pos.Y][pos.X].Visible = true
-			level.Map[pos.Y][pos.X].Visited = true
-			if !level.canSeeThrough(pos) {
-				return
-			}
-			err += deltaY
-			if 2*err >= deltaX {
-				y += ystep
-				err -= deltaX
-			}
-		}
-	} else {
-		for x := start.X; x < end.X; x++ {
-			var pos Pos
-			if steep {
-				pos = Pos{y, x}
-			} else {
-				pos = Pos{x, y}
-			}
-			level.Map[pos.Y][pos.X].Visible = true
-			level.Map[pos.Y][pos.X].Visited = true
-			if !level.canSeeThrough(pos) {
-				return
-			}
-			err += deltaY
-			if 2*err >= deltaX {
-				y += ystep
-				err -= deltaX
-			}
-		}
-	}
-}
-
 func (level *Level) checkClosedDoor(pos Pos) bool {
 	t := level.Map[pos.Y][pos.X]
 	switch t.OverlayRune {
 	case ClosedDoor:
+		if _, locked := level.Locks[pos]; locked {
+			level.emit(Event{Kind: Locked, Actor: level.Player.Name, Target: doorName, Pos: pos})
+			return false
+		}
 		t.OverlayRune = OpenedDoor
 		t.canSee = true
 		t.canWalk = true
 		level.Map[pos.Y][pos.X] = t
-		level.LastEvents = append(level.LastEvents, DoorOpen)
+		level.emit(Event{Kind: DoorOpen, Actor: level.Player.Name, Pos: pos})
 		return true
 	}
 	return false
@@ -220,7 +367,7 @@ func (level *Level) checkOpenedDoor(pos Pos) bool {
 		t.canSee = false
 		t.canWalk = false
 		level.Map[pos.Y][pos.X] = t
-		level.LastEvents = append(level.LastEvents, DoorClose)
+		level.emit(Event{Kind: DoorClose, Actor: level.Player.Name, Pos: pos})
 		return true
 	}
 	return false
@@ -235,43 +382,80 @@ func (level *Level) resetVisibility() {
 }
 
 func (level *Level) resolveVisibility() {
-	pos := level.Player.Pos
-	dist := level.Player.SightRange + 2
-	for y := pos.Y - dist; y <= pos.Y+dist; y++ {
-		for x := pos.X - dist; x <= pos.X+dist; x++ {
-			xDelta := pos.X - x
-			yDelta := pos.Y - y
-			if xDelta*xDelta+yDelta*yDelta < dist*dist {
-				level.bresenhamVisibility(pos, Pos{x, y})
-			}
+	// only tiles seen last time need to be hidden again
+	if level.visible == nil {
+		level.resetVisibility()
+	}
+	for _, pos := range level.visible {
+		level.Map[pos.Y][pos.X].Visible = false
+		level.Map[pos.Y][pos.X].Light = 0
+	}
+
+	light := level.lightMap()
+	level.visible = level.FOV(level.Player.Pos, level.Player.SightRange+1)
+	for _, pos := range level.visible {
+		level.Map[pos.Y][pos.X].Light = level.lightAt(light, pos)
+		if level.isLit(pos, 0) {
+			level.Map[pos.Y][pos.X].Visible = true
+			level.Map[pos.Y][pos.X].Visited = true
 		}
 	}
-	level.bresenhamVisibility(level.Player.Pos, Pos{level.Player.X, level.Player.Y + level.Player.SightRange})
 }
 
 func (level *Level) getNeighbors(pos Pos) []Pos {
+	return level.neighbors(pos, level.canWalk)
+}
+
+func (level *Level) neighbors(pos Pos, passable func(Pos) bool) []Pos {
 	neighbors := make([]Pos, 0, 4)
 	left := Pos{pos.X - 1, pos.Y}
 	right := Pos{pos.X + 1, pos.Y}
 	up := Pos{pos.X, pos.Y - 1}
 	down := Pos{pos.X, pos.Y + 1}
 
-	if level.canWalk(left) {
+	if passable(left) {
 		neighbors = append(neighbors, left)
 	}
-	if level.canWalk(right) {
+	if passable(right) {
 		neighbors = append(neighbors, right)
 	}
-	if level.canWalk(up) {
+	if passable(up) {
 		neighbors = append(neighbors, up)
 	}
-	if level.canWalk(down) {
+	if passable(down) {
 		neighbors = append(neighbors, down)
 	}
 
+	if level.diagonal {
+		for _, diagonal := range []Pos{{pos.X - 1, pos.Y - 1}, {pos.X + 1, pos.Y - 1}, {pos.X - 1, pos.Y + 1}, {pos.X + 1, pos.Y + 1}} {
+			if passable(diagonal) && level.canStep(pos, diagonal) {
+				neighbors = append(neighbors, diagonal)
+			}
+		}
+	}
+
 	return neighbors
 }
 
+// diagonal steps can not cut corners of walls and closed doors nor go through doorways
+func (level *Level) canStep(from, to Pos) bool {
+	if from.X == to.X || from.Y == to.Y {
+		return true
+	}
+	if !level.diagonal || level.isDoor(from) || level.isDoor(to) {
+		return false
+	}
+	return level.canWalk(Pos{to.X, from.Y}) && level.canWalk(Pos{from.X, to.Y})
+}
+
+func (level *Level) isDoor(pos Pos) bool {
+	if !level.inRange(pos) {
+		return false
+	}
+	overlay := level.Map[pos.Y][pos.X].OverlayRune
+	return overlay == ClosedDoor || overlay == OpenedDoor
+}
+
 func (level *Level) BfsFloor(start Pos) rune {
 	frontier := make([]Pos, 0, 8)
 	frontier = append(frontier, start)
@@ -308,7 +492,28 @@ func (level *Level) addEvent(s string) {
 	}
 }
 
+// costs are scaled by ten so diagonal steps can cost 14, the octile distance
+func (level *Level) heuristic(pos Pos, goal Pos) int {
+	xDist := int(math.Abs(float64(goal.X - pos.X)))
+	yDist := int(math.Abs(float64(goal.Y - pos.Y)))
+	if !level.diagonal {
+		return 10 * (xDist + yDist)
+	}
+	if xDist < yDist {
+		return 10*yDist + 4*xDist
+	}
+	return 10*xDist + 4*yDist
+}
+
+func (level *Level) Path(start Pos, goal Pos) []Pos {
+	return level.astar(start, goal)
+}
+
 func (level *Level) astar(start Pos, goal Pos) []Pos {
+	return level.findPath(start, goal, level.canWalk)
+}
+
+func (level *Level) findPath(start Pos, goal Pos, passable func(Pos) bool) []Pos {
 	frontier := make(pqueue, 0, 8)
 	frontier = frontier.push(start, 1)
 	cameFrom := make(map[Pos]Pos)
@@ -333,22 +538,23 @@ func (level *Level) astar(start Pos, goal Pos) []Pos {
 			return path
 		}
 
-		for _, next := range level.getNeighbors(current) {
+		for _, next := range level.neighbors(current, passable) {
 			newCost := costSoFar[current]
 
+			if next.X != current.X && next.Y != current.Y {
+				newCost += 14
+			} else {
+				newCost += 10
+			}
 			_, exists := level.AliveMonstersPos[next]
 			if exists {
-				newCost += 10
-			} else {
-				newCost += 1
+				newCost += 100
 			}
 
 			_, exists = costSoFar[next]
 			if !exists || newCost < costSoFar[next] {
 				costSoFar[next] = newCost
-				xDist := int(math.Abs(float64(goal.X - next.X)))
-				yDist := int(math.Abs(float64(goal.Y - next.Y)))
-				priority := newCost + xDist + yDist
+				priority := newCost + level.heuristic(next, goal)
 				frontier = frontier.push(next, priority)
 				cameFrom[next] = current
 			}
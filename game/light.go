@@ -0,0 +1,76 @@
+package game
+
+import "math"
+
+// monsters need this much more light than the floor they stand on to be spotted
+const monsterContrast = 0.25
+
+type lightSource struct {
+	pos    Pos
+	radius int
+}
+
+func (c *Character) lightRadius() int {
+	radius := c.Light
+	for _, item := range []*Item{c.Helmet, c.Weapon, c.Armor} {
+		if item != nil && item.kind.Light > radius {
+			radius = item.kind.Light
+		}
+	}
+	return radius
+}
+
+func (level *Level) lightSources() []lightSource {
+	sources := make([]lightSource, 0)
+	if radius := level.Player.lightRadius(); radius > 0 {
+		sources = append(sources, lightSource{level.Player.Pos, radius})
+	}
+	for _, monster := range level.Monsters {
+		if radius := monster.lightRadius(); monster.IsAlive() && radius > 0 {
+			sources = append(sources, lightSource{monster.Pos, radius})
+		}
+	}
+	for pos, items := range level.Items {
+		for _, item := range items {
+			if item.kind.Light > 0 {
+				sources = append(sources, lightSource{pos, item.kind.Light})
+			}
+		}
+	}
+	return sources
+}
+
+// lightMap sums the ambient light with every source fading linearly towards its radius
+func (level *Level) lightMap() map[Pos]float64 {
+	light := make(map[Pos]float64)
+	for _, source := range level.lightSources() {
+		for _, pos := range level.FOV(source.pos, source.radius) {
+			light[pos] += 1 - distanceTo(source.pos, pos)/float64(source.radius+1)
+		}
+	}
+	return light
+}
+
+func (level *Level) lightAt(light map[Pos]float64, pos Pos) float64 {
+	return math.Min(1, level.Ambient+light[pos])
+}
+
+// the farther a tile is the more light it needs to be seen, neighbors are always felt
+func (level *Level) isLit(pos Pos, contrast float64) bool {
+	d := distanceTo(level.Player.Pos, pos)
+	if d < 2 {
+		return true
+	}
+	required := math.Min(1, d/float64(level.Player.SightRange+1)+contrast)
+	return level.Map[pos.Y][pos.X].Light >= required
+}
+
+// spotted tells whether a monster standing at pos is noticed by the player
+func (level *Level) spotted(pos Pos) bool {
+	return level.Map[pos.Y][pos.X].Visible && level.isLit(pos, monsterContrast)
+}
+
+func distanceTo(a, b Pos) float64 {
+	dx, dy := float64(a.X-b.X), float64(a.Y-b.Y)
+	return math.Sqrt(dx*dx + dy*dy)
+}
@@ -0,0 +1,54 @@
+package game
+
+const doorName = "Door"
+
+func (c *Character) findKey(keyID string) *Item {
+	for _, item := range c.Items {
+		if item.Typ == Key && item.KeyID == keyID {
+			return item
+		}
+	}
+	return nil
+}
+
+// doors are unlocked from next to them, chests from the tile they stand on
+func (game *Game) isLocked(pos Pos) bool {
+	level := game.CurrentLevel
+	if _, locked := level.Locks[pos]; locked {
+		return true
+	}
+	storage := level.Storages[pos]
+	return pos == game.Player.Pos && storage != nil && storage.Locked
+}
+
+func (game *Game) unlock(pos Pos) ActionType {
+	level := game.CurrentLevel
+	keyID, target := level.Locks[pos], doorName
+	storage := level.Storages[pos]
+	if keyID == "" && storage != nil {
+		keyID, target = storage.KeyID, storage.Name
+	}
+
+	key := game.Player.findKey(keyID)
+	if key == nil {
+		level.emit(Event{Kind: Locked, Actor: game.Player.Name, Target: target, Pos: pos})
+		return ANone
+	}
+	if _, door := level.Locks[pos]; door {
+		delete(level.Locks, pos)
+	} else {
+		storage.Locked = false
+	}
+	view := game.itemView(key)
+	level.emit(Event{Kind: Unlock, Actor: game.Player.Name, Target: target, Pos: pos, Item: &view})
+	return AUnlock
+}
+
+func (game *Game) storageLocked() bool {
+	storage := game.CurrentLevel.Storages[game.Player.Pos]
+	if storage == nil || !storage.Locked {
+		return false
+	}
+	game.CurrentLevel.emit(Event{Kind: Locked, Actor: game.Player.Name, Target: storage.Name, Pos: storage.Pos})
+	return true
+}
@@ -0,0 +1,72 @@
+package game
+
+import "testing"
+
+func newTestKey(game *Game, keyID string) *Item {
+	registry := &ItemRegistry{index: newDefIndex()}
+	def := &ItemDef{Name: "Key", Rune: 'k', Sprite: Sprite{0, 0, 1}, Slot: Key, registry: registry}
+	registry.index.add(def)
+	key := def.Spawn(game.Player.Pos)
+	key.KeyID = keyID
+	return key
+}
+
+func TestUnlockDoor(t *testing.T) {
+	game := newTestGame(t,
+		"######",
+		"#@|..#",
+		"######",
+	)
+	door := Pos{2, 1}
+	game.CurrentLevel.Locks[door] = "iron"
+
+	game.Step(&Input{Typ: IAction, Direction: DRight})
+	if countEvents(game.CurrentLevel, Locked) != 1 {
+		t.Error("no locked event without the key")
+	}
+	game.Step(&Input{Typ: IMove, Direction: DRight})
+	if overlay := game.CurrentLevel.Map[door.Y][door.X].OverlayRune; overlay != ClosedDoor {
+		t.Fatalf("locked door shows %q after bumping into it", overlay)
+	}
+
+	// a key to another lock does not fit
+	game.Player.Items = append(game.Player.Items, newTestKey(game, "rusty"))
+	game.Step(&Input{Typ: IAction, Direction: DRight})
+	if _, locked := game.CurrentLevel.Locks[door]; !locked {
+		t.Fatal("wrong key unlocked the door")
+	}
+
+	game.Player.Items = append(game.Player.Items, newTestKey(game, "iron"))
+	game.Step(&Input{Typ: IAction, Direction: DRight})
+	if countEvents(game.CurrentLevel, Unlock) != 1 {
+		t.Error("no unlock event with the key")
+	}
+	if _, locked := game.CurrentLevel.Locks[door]; locked {
+		t.Fatal("door is still locked")
+	}
+	game.Step(&Input{Typ: IMove, Direction: DRight})
+	game.Step(&Input{Typ: IMove, Direction: DRight})
+	if game.Player.Pos != door {
+		t.Errorf("player at %v, want the unlocked door %v", game.Player.Pos, door)
+	}
+}
+
+func TestUnlockChest(t *testing.T) {
+	game := newTestGame(t,
+		"####",
+		"#@.#",
+		"####",
+	)
+	chest := NewChest(game.Player.Pos, &StorageConf{locked: true, key: "rusty"})
+	game.CurrentLevel.AddStorage(chest)
+
+	game.Step(&Input{Typ: IAction, Direction: DNone})
+	if !chest.Locked || countEvents(game.CurrentLevel, Locked) != 1 {
+		t.Fatal("chest opened without the key")
+	}
+	game.Player.Items = append(game.Player.Items, newTestKey(game, "rusty"))
+	game.Step(&Input{Typ: IAction, Direction: DNone})
+	if chest.Locked {
+		t.Error("key did not unlock the chest")
+	}
+}
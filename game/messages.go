@@ -0,0 +1,151 @@
+package game
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const fallbackLanguage = "en"
+
+type Catalog struct {
+	Language string
+	messages map[string]string
+}
+
+func LoadCatalog(dir, language string) (*Catalog, error) {
+	catalog := &Catalog{language, make(map[string]string)}
+	languages := []string{fallbackLanguage}
+	if language != fallbackLanguage {
+		languages = append(languages, language)
+	}
+
+	var errs ErrorList
+	for _, lang := range languages {
+		errs.add(catalog.loadFile(filepath.Join(dir, lang+".lang")))
+	}
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+func (catalog *Catalog) loadFile(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var errs ErrorList
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		separator := strings.Index(line, "=")
+		if separator < 0 {
+			errs.add(&LoadError{File: filename, Line: lineNumber, Column: len(line) + 1, Err: ErrMissingField})
+			continue
+		}
+		id := strings.TrimSpace(line[:separator])
+		if seen[id] {
+			errs.add(&LoadError{File: filename, Line: lineNumber, Column: 1, Err: ErrDuplicateKey})
+			continue
+		}
+		seen[id] = true
+		catalog.messages[id] = strings.TrimSpace(line[separator+1:])
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return errs.err()
+}
+
+func (catalog *Catalog) Render(id string, params map[string]string) string {
+	template, exists := catalog.messages[id]
+	if !exists {
+		// missing translations stay visible
+		return id
+	}
+	replacements := make([]string, 0, len(params)*2)
+	for key, value := range params {
+		replacements = append(replacements, "{"+key+"}", value)
+	}
+	return strings.NewReplacer(replacements...).Replace(template)
+}
+
+func (catalog *Catalog) Name(name string) string {
+	if translated, exists := catalog.messages["name."+name]; exists {
+		return translated
+	}
+	return name
+}
+
+func (game *Game) logMessage(id string, params map[string]string) {
+	game.CurrentLevel.addEvent(game.catalog.Render(id, params))
+}
+
+func (game *Game) logError(id string, err error) {
+	game.logMessage(id, map[string]string{"error": err.Error()})
+}
+
+func (game *Game) logEvent(event Event) {
+	params := map[string]string{
+		"actor":  game.catalog.Name(event.Actor),
+		"target": game.catalog.Name(event.Target),
+		"damage": strconv.Itoa(event.Damage),
+		"from":   game.catalog.Name(event.FromLevel),
+		"to":     game.catalog.Name(event.ToLevel),
+	}
+	if event.Item != nil {
+		params["item"] = game.catalog.Name(event.Item.Name)
+	}
+
+	switch event.Kind {
+	case Attack:
+		if event.Killed {
+			game.logMessage("attack.kill", params)
+		} else {
+			game.logMessage("attack.hit", params)
+		}
+		if event.Killed && event.Target == game.Player.Name {
+			game.logMessage("player.died", params)
+		}
+	case DoorOpen:
+		game.logMessage("door.open", params)
+	case DoorClose:
+		game.logMessage("door.close", params)
+	case Portal:
+		game.logMessage("portal.travel", params)
+	case PickUp:
+		game.logMessage("item.pickup", params)
+	case DropDown:
+		game.logMessage("item.drop", params)
+	case Equip:
+		game.logMessage("item.equip", params)
+	case TakeOff:
+		game.logMessage("item.strip", params)
+	case Notice:
+		game.logMessage("monster.notice", params)
+	case Locked:
+		game.logMessage("lock.locked", params)
+	case Unlock:
+		game.logMessage("lock.unlock", params)
+	case Use:
+		game.logMessage("item.use", params)
+	case Heal:
+		game.logMessage("item.heal", params)
+	case Teleport:
+		game.logMessage("item.teleport", params)
+	case Overloaded:
+		game.logMessage("item.heavy", params)
+	}
+}
@@ -1,64 +1,143 @@
 package game
 
+type Awareness int
+
+const (
+	Asleep Awareness = iota
+	Unaware
+	Searching
+	Hunting
+)
+
+// turns a searching monster keeps looking before it gives up
+const searchTurns = 15
+
 type Monster struct {
 	Character
+	Awareness Awareness
+	LastSeen  Pos
+	LostTurns int
+
+	kind *MonsterDef
+}
+
+func (m *Monster) Act(level *Level) ActionType {
+	m.perceive(level)
+	switch m.Awareness {
+	case Hunting:
+		return m.hunt(level)
+	case Searching:
+		m.LostTurns++
+		if m.LostTurns > searchTurns {
+			m.Awareness = Unaware
+		} else if m.Pos != m.LastSeen {
+			return m.approach(level, m.LastSeen)
+		}
+	}
+	return AWait
+}
+
+func (m *Monster) perceive(level *Level) {
+	if m.Awareness == Asleep {
+		return
+	}
+	if m.canSee(level, level.Player.Pos) {
+		// monsters the player cannot make out give no warning
+		if m.Awareness != Hunting && level.spotted(m.Pos) {
+			level.emit(Event{Kind: Notice, Actor: m.Name, Target: level.Player.Name, Pos: m.Pos})
+		}
+		m.Awareness = Hunting
+		m.LastSeen = level.Player.Pos
+		m.LostTurns = 0
+	} else if m.Awareness == Hunting {
+		m.Awareness = Searching
+	}
+}
+
+func (m *Monster) canSee(level *Level, pos Pos) bool {
+	dx, dy := pos.X-m.X, pos.Y-m.Y
+	if dx*dx+dy*dy > m.SightRange*m.SightRange {
+		return false
+	}
+	for _, visible := range level.FOV(m.Pos, m.SightRange) {
+		if visible == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// hearing wakes the monster and makes it search where the noise came from
+func (m *Monster) hear(pos Pos, loudness int) {
+	if m.Awareness == Hunting {
+		return
+	}
+	if m.Awareness == Asleep {
+		loudness /= 2
+	}
+	dx, dy := pos.X-m.X, pos.Y-m.Y
+	if dx*dx+dy*dy > loudness*loudness {
+		return
+	}
+	m.Awareness = Searching
+	m.LastSeen = pos
+	m.LostTurns = 0
 }
 
-func NewRat(pos Pos) *Monster {
-	monster := &Monster{}
-	monster.Pos = pos
-	monster.Rune = 'R'
-	monster.Name = "Rat"
-	monster.Hitpoints = 5
-	monster.Strength = 5
-	monster.Speed = 2.0
-	monster.ActionPoints = 0.0
-	monster.SightRange = 10
-	return monster
+var actionNoise = map[ActionType]int{
+	AMove:      2,
+	AAttack:    8,
+	AOpenDoor:  6,
+	ACloseDoor: 6,
 }
 
-func NewSpider(pos Pos) *Monster {
-	monster := &Monster{}
-	monster.Pos = pos
-	monster.Rune = 'S'
-	monster.Name = "Spider"
-	monster.Hitpoints = 10
-	monster.Strength = 10
-	monster.Speed = 1.0
-	monster.ActionPoints = 0.0
-	monster.SightRange = 10
-	return monster
+func (level *Level) noise(pos Pos, loudness int) {
+	if loudness == 0 {
+		return
+	}
+	for _, monster := range level.Monsters {
+		if monster.IsAlive() {
+			monster.hear(pos, loudness)
+		}
+	}
 }
 
-func (m *Monster) Update(level *Level) {
-	m.ActionPoints += m.Speed
-	positions := level.astar(m.Pos, level.Player.Pos)
-	for m.ActionPoints >= 1 {
-		if positions != nil {
-			m.ActionPoints--
-			next := positions[0]
-			if next == level.Player.Pos {
-				event := m.Attack(&level.Player.Character)
-				level.addEvent(event)
-				if !level.Player.IsAlive() {
-					level.addEvent("DED")
-				}
-			} else {
-				moved := m.Move(level, next)
-				if moved {
-					positions = positions[1:]
-				} else {
-					m.Pass()
-				}
-			}
-		} else {
-			m.Pass()
+func (m *Monster) hunt(level *Level) ActionType {
+	field := level.approachMap()
+	switch m.profile() {
+	case AICoward:
+		if m.Hitpoints*2 < m.MaxHitpoints {
+			field = level.fleeMap()
+		}
+	case AISkirmisher:
+		if field.At(m.Pos) > 1 {
+			field = level.KiteMap(field, skirmishDistance)
 		}
 	}
+	next, ok := level.Downhill(field, m.Pos)
+	if !ok {
+		return AWait
+	}
+	return m.step(level, next)
+}
+
+func (m *Monster) approach(level *Level, goal Pos) ActionType {
+	positions := level.astar(m.Pos, goal)
+	if len(positions) == 0 {
+		return AWait
+	}
+	return m.step(level, positions[0])
 }
 
-func (m *Monster) Pass() {
-	m.ActionPoints = 0
+func (m *Monster) step(level *Level, next Pos) ActionType {
+	if next == level.Player.Pos {
+		level.emit(m.Attack(&level.Player.Character, level.rng))
+		return AAttack
+	}
+	if m.Move(level, next) {
+		return AMove
+	}
+	return AWait
 }
 
 func (m *Monster) Move(level *Level, next Pos) bool {
@@ -73,10 +152,25 @@ func (m *Monster) Move(level *Level, next Pos) bool {
 	return true
 }
 
+func (m *Monster) profile() AIProfile {
+	if m.kind == nil {
+		return AIHunter
+	}
+	return m.kind.AI
+}
+
 func (m *Monster) Kill(level *Level) {
 	delete(level.AliveMonstersPos, m.Pos)
 	for _, item := range m.Items {
 		item.Pos = m.Pos
 		level.Items[m.Pos] = append(level.Items[m.Pos], item)
 	}
+	if m.kind == nil {
+		return
+	}
+	for _, loot := range m.kind.Loot {
+		if level.rng.Float64() < loot.Chance {
+			level.AddItem(loot.Item.Spawn(m.Pos))
+		}
+	}
 }
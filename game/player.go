@@ -10,6 +10,7 @@ func NewPlayer(pos Pos) *Player {
 	player.Rune = '@'
 	player.Name = "Player"
 	player.Hitpoints = 20
+	player.MaxHitpoints = 20
 	player.Strength = 20
 	player.Speed = 1.0
 	player.ActionPoints = 0.0
@@ -0,0 +1,31 @@
+package game
+
+import "math/rand"
+
+type countingSource struct {
+	source rand.Source64
+	draws  uint64
+}
+
+func newRandom(seed int64, draws uint64) (*rand.Rand, *countingSource) {
+	source := &countingSource{source: rand.NewSource(seed).(rand.Source64)}
+	for source.draws < draws {
+		source.Uint64()
+	}
+	return rand.New(source), source
+}
+
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.source.Int63()
+}
+
+func (s *countingSource) Uint64() uint64 {
+	s.draws++
+	return s.source.Uint64()
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.draws = 0
+	s.source.Seed(seed)
+}
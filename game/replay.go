@@ -0,0 +1,67 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const replayVersion = 1
+
+type replayHeader struct {
+	Version int
+	Conf    GameConf
+}
+
+func (game *Game) Record(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(&replayHeader{replayVersion, game.conf}); err != nil {
+		return err
+	}
+	game.recorder = encoder
+	return nil
+}
+
+func (game *Game) record(input *Input) {
+	if game.recorder == nil {
+		return
+	}
+	if err := game.recorder.Encode(input); err != nil {
+		game.recorder = nil
+		game.logError("game.record-failed", err)
+	}
+}
+
+func Replay(r io.Reader, handlers ...EventHandler) (*Game, error) {
+	decoder := json.NewDecoder(r)
+	var header replayHeader
+	if err := decoder.Decode(&header); err != nil {
+		return nil, err
+	}
+	if header.Version != replayVersion {
+		return nil, fmt.Errorf("unsupported replay version %d", header.Version)
+	}
+
+	game, err := NewGame(&header.Conf)
+	if err != nil {
+		return nil, err
+	}
+	for _, handler := range handlers {
+		game.Subscribe(handler)
+	}
+	game.Start()
+
+	for {
+		var input Input
+		err := decoder.Decode(&input)
+		if err == io.EOF {
+			return game, nil
+		}
+		if err != nil {
+			return game, err
+		}
+		if !game.Step(&input) {
+			return game, nil
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package game
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReplayItemInputs(t *testing.T) {
+	live, err := NewGame(testConf())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recording bytes.Buffer
+	if err := live.Record(&recording); err != nil {
+		t.Fatal(err)
+	}
+	live.Start()
+
+	// the gold lies right below the starting position
+	live.Step(&Input{Typ: IMove, Direction: DDown})
+	view := live.View()
+	ground := view.Items[view.Player.Pos]
+	if len(ground) == 0 {
+		t.Fatalf("no item at %v", view.Player.Pos)
+	}
+	live.Step(&Input{Typ: ITakeItem, ItemID: ground[0].ID, Quantity: 10})
+	live.Step(&Input{Typ: IMove, Direction: DRight})
+	if len(live.Player.Items) != 1 {
+		t.Fatalf("live game carries %d items, want 1", len(live.Player.Items))
+	}
+
+	replayed, err := Replay(&recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.Player.Pos != live.Player.Pos {
+		t.Errorf("replayed player at %v, live at %v", replayed.Player.Pos, live.Player.Pos)
+	}
+	if len(replayed.Player.Items) != len(live.Player.Items) {
+		t.Fatalf("replayed game carries %d items, live %d", len(replayed.Player.Items), len(live.Player.Items))
+	}
+	for i, item := range live.Player.Items {
+		other := replayed.Player.Items[i]
+		if other.ID != item.ID || other.Name != item.Name || other.Quantity != item.Quantity {
+			t.Errorf("replayed item %d is %s x%d (#%d), live %s x%d (#%d)",
+				i, other.Name, other.Quantity, other.ID, item.Name, item.Quantity, item.ID)
+		}
+	}
+}
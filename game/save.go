@@ -0,0 +1,454 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+const (
+	saveVersion   = 8
+	quickSaveFile = "quicksave.sav"
+	noItem        = -1
+)
+
+type savedGame struct {
+	Version      int
+	Conf         GameConf
+	RandomDraws  uint64
+	CurrentLevel string
+	NextItemID   int
+	Items        []savedItem
+	Player       savedCharacter
+	Levels       []savedLevel
+}
+
+type savedItem struct {
+	Entity
+	ID       int
+	KeyID    string
+	Quantity int
+}
+
+type savedCharacter struct {
+	Entity
+	Items        []int
+	Hitpoints    int
+	MaxHitpoints int
+	Regeneration int
+	Strength     int
+	Speed        float64
+	ActionPoints float64
+	SightRange   int
+	Light        int
+	Helmet       int
+	Weapon       int
+	Armor        int
+}
+
+type savedMonster struct {
+	savedCharacter
+	Awareness Awareness
+	LastSeen  Pos
+	LostTurns int
+}
+
+type savedTile struct {
+	Rune        rune
+	OverlayRune rune
+	Visible     bool
+	Visited     bool
+	CanWalk     bool
+	CanSee      bool
+}
+
+type savedGround struct {
+	Pos   Pos
+	Items []int
+}
+
+type savedStorage struct {
+	Entity
+	Items  []int
+	Locked bool
+	KeyID  string
+}
+
+type savedPortal struct {
+	Pos      Pos
+	Name     string
+	KeyID    string
+	DstLevel string
+	DstPos   Pos
+}
+
+type savedLock struct {
+	Pos   Pos
+	KeyID string
+}
+
+type savedLevel struct {
+	Name     string
+	Depth    int
+	Music    string
+	Ambient  float64
+	Map      [][]savedTile
+	Monsters []savedMonster
+	Ground   []savedGround
+	Storages []savedStorage
+	Portals  []savedPortal
+	Locks    []savedLock
+	Log      []string
+}
+
+type saver struct {
+	ids   map[*Item]int
+	items []savedItem
+	names map[*Level]string
+}
+
+func (s *saver) ref(item *Item) int {
+	if item == nil {
+		return noItem
+	}
+	id, exists := s.ids[item]
+	if !exists {
+		id = len(s.items)
+		s.ids[item] = id
+		s.items = append(s.items, savedItem{item.Entity, item.ID, item.KeyID, item.Quantity})
+	}
+	return id
+}
+
+func (s *saver) refs(items []*Item) []int {
+	ids := make([]int, len(items))
+	for i, item := range items {
+		ids[i] = s.ref(item)
+	}
+	return ids
+}
+
+func (s *saver) character(c *Character) savedCharacter {
+	return savedCharacter{
+		Entity:       c.Entity,
+		Items:        s.refs(c.Items),
+		Hitpoints:    c.Hitpoints,
+		MaxHitpoints: c.MaxHitpoints,
+		Regeneration: c.Regeneration,
+		Strength:     c.Strength,
+		Speed:        c.Speed,
+		ActionPoints: c.ActionPoints,
+		SightRange:   c.SightRange,
+		Light:        c.Light,
+		Helmet:       s.ref(c.Helmet),
+		Weapon:       s.ref(c.Weapon),
+		Armor:        s.ref(c.Armor),
+	}
+}
+
+func (s *saver) level(name string, level *Level) savedLevel {
+	saved := savedLevel{Name: name, Depth: level.Depth, Music: level.Music, Ambient: level.Ambient, Log: level.Log}
+
+	saved.Map = make([][]savedTile, len(level.Map))
+	for y, row := range level.Map {
+		saved.Map[y] = make([]savedTile, len(row))
+		for x, t := range row {
+			saved.Map[y][x] = savedTile{t.Rune, t.OverlayRune, t.Visible, t.Visited, t.canWalk, t.canSee}
+		}
+	}
+
+	for _, monster := range level.Monsters {
+		saved.Monsters = append(saved.Monsters, savedMonster{s.character(&monster.Character), monster.Awareness, monster.LastSeen, monster.LostTurns})
+	}
+
+	groundPositions := make([]Pos, 0, len(level.Items))
+	for pos, items := range level.Items {
+		if len(items) > 0 {
+			groundPositions = append(groundPositions, pos)
+		}
+	}
+	for _, pos := range sortPositions(groundPositions) {
+		saved.Ground = append(saved.Ground, savedGround{pos, s.refs(level.Items[pos])})
+	}
+
+	storagePositions := make([]Pos, 0, len(level.Storages))
+	for pos := range level.Storages {
+		storagePositions = append(storagePositions, pos)
+	}
+	for _, pos := range sortPositions(storagePositions) {
+		storage := level.Storages[pos]
+		saved.Storages = append(saved.Storages, savedStorage{storage.Entity, s.refs(storage.Items), storage.Locked, storage.KeyID})
+	}
+
+	portalPositions := make([]Pos, 0, len(level.Portals))
+	for pos := range level.Portals {
+		portalPositions = append(portalPositions, pos)
+	}
+	for _, pos := range sortPositions(portalPositions) {
+		portal := level.Portals[pos]
+		saved.Portals = append(saved.Portals, savedPortal{pos, portal.Name, portal.KeyID, s.names[portal.level], portal.pos})
+	}
+
+	lockPositions := make([]Pos, 0, len(level.Locks))
+	for pos := range level.Locks {
+		lockPositions = append(lockPositions, pos)
+	}
+	for _, pos := range sortPositions(lockPositions) {
+		saved.Locks = append(saved.Locks, savedLock{pos, level.Locks[pos]})
+	}
+
+	return saved
+}
+
+func sortPositions(positions []Pos) []Pos {
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].Y != positions[j].Y {
+			return positions[i].Y < positions[j].Y
+		}
+		return positions[i].X < positions[j].X
+	})
+	return positions
+}
+
+func (game *Game) Save(w io.Writer) error {
+	s := &saver{ids: make(map[*Item]int), names: make(map[*Level]string)}
+	names := make([]string, 0, len(game.Levels))
+	for name, level := range game.Levels {
+		s.names[level] = name
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	saved := savedGame{
+		Version:      saveVersion,
+		Conf:         game.conf,
+		RandomDraws:  game.randomSource.draws,
+		CurrentLevel: s.names[game.CurrentLevel],
+		NextItemID:   game.itemDefs.lastID,
+	}
+	saved.Player = s.character(&game.Player.Character)
+	for _, name := range names {
+		saved.Levels = append(saved.Levels, s.level(name, game.Levels[name]))
+	}
+	saved.Items = s.items
+
+	return json.NewEncoder(w).Encode(&saved)
+}
+
+type restorer struct {
+	items    []*Item
+	levels   map[string]*Level
+	bestiary *Bestiary
+}
+
+func (r *restorer) ref(id int) (*Item, error) {
+	if id == noItem {
+		return nil, nil
+	}
+	if id < 0 || id >= len(r.items) {
+		return nil, fmt.Errorf("invalid item reference %d", id)
+	}
+	return r.items[id], nil
+}
+
+func (r *restorer) refs(ids []int) ([]*Item, error) {
+	items := make([]*Item, 0, len(ids))
+	for _, id := range ids {
+		item, err := r.ref(id)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (r *restorer) character(saved *savedCharacter, c *Character) error {
+	var err error
+	c.Entity = saved.Entity
+	c.Hitpoints = saved.Hitpoints
+	c.MaxHitpoints = saved.MaxHitpoints
+	c.Regeneration = saved.Regeneration
+	c.Strength = saved.Strength
+	c.Speed = saved.Speed
+	c.ActionPoints = saved.ActionPoints
+	c.SightRange = saved.SightRange
+	c.Light = saved.Light
+	if c.Items, err = r.refs(saved.Items); err != nil {
+		return err
+	}
+	if c.Helmet, err = r.ref(saved.Helmet); err != nil {
+		return err
+	}
+	if c.Weapon, err = r.ref(saved.Weapon); err != nil {
+		return err
+	}
+	if c.Armor, err = r.ref(saved.Armor); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *restorer) level(saved *savedLevel, level *Level) error {
+	level.Map = make([][]Tile, len(saved.Map))
+	for y, row := range saved.Map {
+		level.Map[y] = make([]Tile, len(row))
+		for x, t := range row {
+			level.Map[y][x] = Tile{Rune: t.Rune, OverlayRune: t.OverlayRune, Visible: t.Visible, Visited: t.Visited, canWalk: t.CanWalk, canSee: t.CanSee}
+		}
+	}
+
+	for i := range saved.Monsters {
+		monster := &Monster{Awareness: saved.Monsters[i].Awareness, LastSeen: saved.Monsters[i].LastSeen, LostTurns: saved.Monsters[i].LostTurns}
+		if err := r.character(&saved.Monsters[i].savedCharacter, &monster.Character); err != nil {
+			return err
+		}
+		kind, exists := r.bestiary.Named(monster.Name)
+		if !exists {
+			return fmt.Errorf("unknown monster %q", monster.Name)
+		}
+		monster.kind = kind
+		level.Monsters = append(level.Monsters, monster)
+		if monster.IsAlive() {
+			level.AliveMonstersPos[monster.Pos] = monster
+		}
+	}
+
+	for _, ground := range saved.Ground {
+		items, err := r.refs(ground.Items)
+		if err != nil {
+			return err
+		}
+		level.Items[ground.Pos] = items
+	}
+
+	for _, savedStorage := range saved.Storages {
+		items, err := r.refs(savedStorage.Items)
+		if err != nil {
+			return err
+		}
+		storage := &Storage{Repository{savedStorage.Entity, items}, savedStorage.Locked, savedStorage.KeyID}
+		level.AddStorage(storage)
+	}
+
+	for _, portal := range saved.Portals {
+		dstLevel, exists := r.levels[portal.DstLevel]
+		if !exists {
+			return fmt.Errorf("portal at %v leads to unknown level %q", portal.Pos, portal.DstLevel)
+		}
+		level.Link(portal.Pos, dstLevel, portal.DstPos)
+		level.Portals[portal.Pos].Name = portal.Name
+		level.Portals[portal.Pos].KeyID = portal.KeyID
+	}
+
+	for _, lock := range saved.Locks {
+		level.Locks[lock.Pos] = lock.KeyID
+	}
+
+	level.Depth = saved.Depth
+	level.Music = saved.Music
+	level.Ambient = saved.Ambient
+	level.Log = saved.Log
+	return nil
+}
+
+func Load(r io.Reader) (*Game, error) {
+	var saved savedGame
+	if err := json.NewDecoder(r).Decode(&saved); err != nil {
+		return nil, err
+	}
+	if saved.Version != saveVersion {
+		return nil, fmt.Errorf("unsupported save version %d", saved.Version)
+	}
+
+	itemDefs, bestiary, err := LoadDefinitions(saved.Conf.DataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := &restorer{levels: make(map[string]*Level), bestiary: bestiary}
+	for _, savedItem := range saved.Items {
+		def, exists := itemDefs.Named(savedItem.Name)
+		if !exists {
+			return nil, fmt.Errorf("unknown item %q", savedItem.Name)
+		}
+		item := def.Spawn(savedItem.Pos)
+		item.ID, item.KeyID, item.Quantity = savedItem.ID, savedItem.KeyID, savedItem.Quantity
+		rs.items = append(rs.items, item)
+	}
+	// restored items keep their ids, new ones continue after them
+	itemDefs.lastID = saved.NextItemID
+
+	player := &Player{}
+	if err := rs.character(&saved.Player, &player.Character); err != nil {
+		return nil, err
+	}
+
+	for _, savedLevel := range saved.Levels {
+		rs.levels[savedLevel.Name] = newLevel(player)
+		rs.levels[savedLevel.Name].bestiary = bestiary
+		rs.levels[savedLevel.Name].itemDefs = itemDefs
+	}
+	for i := range saved.Levels {
+		if err := rs.level(&saved.Levels[i], rs.levels[saved.Levels[i].Name]); err != nil {
+			return nil, fmt.Errorf("level %s: %v", saved.Levels[i].Name, err)
+		}
+	}
+
+	currentLevel, exists := rs.levels[saved.CurrentLevel]
+	if !exists {
+		return nil, fmt.Errorf("unknown current level %q", saved.CurrentLevel)
+	}
+
+	catalog, err := LoadCatalog(saved.Conf.LangDir, saved.Conf.Language)
+	if err != nil {
+		return nil, err
+	}
+
+	game := newGame(&saved.Conf, saved.RandomDraws, player, rs.levels)
+	game.catalog = catalog
+	game.bestiary = bestiary
+	game.itemDefs = itemDefs
+	game.CurrentLevel = currentLevel
+	// light is not saved, it is cast again from the restored sources
+	game.CurrentLevel.resolveVisibility()
+	return game, nil
+}
+
+func (game *Game) quickSave() {
+	file, err := os.Create(quickSaveFile)
+	if err != nil {
+		game.logError("game.save-failed", err)
+		return
+	}
+	defer file.Close()
+
+	if err := game.Save(file); err != nil {
+		game.logError("game.save-failed", err)
+		return
+	}
+	game.logMessage("game.saved", nil)
+}
+
+func (game *Game) quickLoad() {
+	file, err := os.Open(quickSaveFile)
+	if err != nil {
+		game.logError("game.load-failed", err)
+		return
+	}
+	defer file.Close()
+
+	loaded, err := Load(file)
+	if err != nil {
+		game.logError("game.load-failed", err)
+		return
+	}
+	loaded.LevelChan, loaded.InputChan = game.LevelChan, game.InputChan
+	loaded.recorder = game.recorder
+	loaded.subscribers = game.subscribers
+	loaded.catalog = game.catalog
+	*game = *loaded
+	game.logMessage("game.loaded", nil)
+}
@@ -0,0 +1,102 @@
+package game
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSaveRoundTrip(t *testing.T) {
+	game, gold := newGoldGame(t)
+	game.Step(&Input{Typ: ITakeItem, ItemID: gold.ID})
+	// the chest with the starting equipment is left of the gold
+	game.Step(&Input{Typ: IMove, Direction: DLeft})
+	storage := game.CurrentLevel.Storages[game.Player.Pos]
+	if storage == nil {
+		t.Fatalf("no chest at %v", game.Player.Pos)
+	}
+	var sword *Item
+	for _, item := range storage.Items {
+		if item.Typ == Weapon {
+			sword = item
+		}
+	}
+	if sword == nil {
+		t.Fatal("chest holds no weapon")
+	}
+	game.Step(&Input{Typ: IWithdrawItem, ItemID: sword.ID})
+	game.Step(&Input{Typ: IEquipItem, ItemID: sword.ID})
+	if game.Player.Weapon != sword {
+		t.Fatal("weapon was not equipped")
+	}
+
+	var first bytes.Buffer
+	if err := game.Save(&first); err != nil {
+		t.Fatal(err)
+	}
+	saved := first.String()
+	loaded, err := Load(&first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var second bytes.Buffer
+	if err := loaded.Save(&second); err != nil {
+		t.Fatal(err)
+	}
+	if second.String() != saved {
+		t.Error("saving a loaded game changed the save")
+	}
+
+	// equipped items are the instances the rest of the game refers to
+	weapon := loaded.Player.Weapon
+	if weapon == nil || weapon.ID != sword.ID {
+		t.Fatalf("loaded weapon %v, want #%d", weapon, sword.ID)
+	}
+	if loaded.findItem(sword.ID) != weapon {
+		t.Error("equipped weapon is not the item found by its id")
+	}
+
+	for name, level := range game.Levels {
+		other := loaded.Levels[name]
+		if other == nil {
+			t.Errorf("level %s was not restored", name)
+			continue
+		}
+		if len(other.Portals) != len(level.Portals) {
+			t.Errorf("level %s has %d portals, want %d", name, len(other.Portals), len(level.Portals))
+		}
+		for pos, portal := range level.Portals {
+			restored := other.Portals[pos]
+			if restored == nil {
+				t.Errorf("portal %s %v was not restored", name, pos)
+				continue
+			}
+			if restored.Name != portal.Name || restored.KeyID != portal.KeyID || restored.pos != portal.pos ||
+				restored.level != loaded.Levels[portal.level.Name] {
+				t.Errorf("portal %s %v leads to %s %v, want %s %v",
+					name, pos, restored.level.Name, restored.pos, portal.level.Name, portal.pos)
+			}
+		}
+		if !reflect.DeepEqual(other.Log, level.Log) {
+			t.Errorf("level %s log is %q, want %q", name, other.Log, level.Log)
+		}
+	}
+	if len(loaded.CurrentLevel.Log) == 0 {
+		t.Error("no messages were restored")
+	}
+
+	if loaded.randomSource.draws != game.randomSource.draws {
+		t.Errorf("loaded game made %d draws, want %d", loaded.randomSource.draws, game.randomSource.draws)
+	}
+	for i := 0; i < 5; i++ {
+		if got, want := loaded.rng.Int63(), game.rng.Int63(); got != want {
+			t.Fatalf("draw %d is %d, want %d", i, got, want)
+		}
+	}
+
+	// checked last, stripping is a turn that logs and draws
+	loaded.Step(&Input{Typ: IStripItem, ItemID: sword.ID})
+	if loaded.Player.Weapon != nil || loaded.Player.Items[len(loaded.Player.Items)-1] != weapon {
+		t.Error("stripped weapon did not move to the inventory")
+	}
+}
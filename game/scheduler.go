@@ -0,0 +1,104 @@
+package game
+
+import "math"
+
+type ActionType int
+
+const (
+	ANone ActionType = iota
+	AMove
+	AAttack
+	AOpenDoor
+	ACloseDoor
+	ATakeItem
+	ADropItem
+	AStoreItem
+	AWithdrawItem
+	AEquip
+	AStrip
+	AWait
+	AUnlock
+	AUseItem
+)
+
+// energy below this is treated as zero to absorb floating point drift
+const energyEpsilon = 1e-9
+
+func DefaultActionCosts() map[ActionType]float64 {
+	return map[ActionType]float64{
+		AMove:         1.0,
+		AAttack:       1.0,
+		AOpenDoor:     1.0,
+		ACloseDoor:    1.0,
+		ATakeItem:     0.5,
+		ADropItem:     0.5,
+		AStoreItem:    0.5,
+		AWithdrawItem: 0.5,
+		AEquip:        1.0,
+		AStrip:        1.0,
+		AUseItem:      1.0,
+		AWait:         1.0,
+		AUnlock:       1.0,
+	}
+}
+
+func (game *Game) actionCost(action ActionType) float64 {
+	if cost, exists := game.conf.ActionCosts[action]; exists {
+		return cost
+	}
+	return DefaultActionCosts()[action]
+}
+
+func (c *Character) ready() bool {
+	return c.ActionPoints >= -energyEpsilon
+}
+
+func (game *Game) runMonsters() {
+	level := game.CurrentLevel
+	player := game.Player
+	// the player moved, monsters share a fresh flow field
+	level.approach, level.flee = nil, nil
+	for player.IsAlive() {
+		var next *Monster
+		for _, monster := range level.Monsters {
+			if monster.IsAlive() && monster.ready() && (next == nil || monster.ActionPoints > next.ActionPoints) {
+				next = monster
+			}
+		}
+
+		if next != nil && (!player.ready() || next.ActionPoints > player.ActionPoints+energyEpsilon) {
+			cost := game.actionCost(next.Act(level))
+			if cost <= 0 {
+				cost = game.actionCost(AWait)
+			}
+			next.ActionPoints -= cost
+			continue
+		}
+		if player.ready() {
+			return
+		}
+
+		// nobody can act, advance time until the nearest actor is ready
+		elapsed := math.Inf(1)
+		playerSpeed := player.speed()
+		if playerSpeed > 0 {
+			elapsed = -player.ActionPoints / playerSpeed
+		}
+		for _, monster := range level.Monsters {
+			if monster.IsAlive() && monster.speed() > 0 {
+				if wait := -monster.ActionPoints / monster.speed(); wait < elapsed {
+					elapsed = wait
+				}
+			}
+		}
+		if math.IsInf(elapsed, 1) {
+			return
+		}
+		player.ActionPoints += playerSpeed * elapsed
+		for _, monster := range level.Monsters {
+			if monster.IsAlive() {
+				monster.ActionPoints += monster.speed() * elapsed
+			}
+		}
+	}
+}
@@ -8,11 +8,13 @@ type Repository struct {
 type Storage struct {
 	Repository
 	Locked bool
+	KeyID  string
 }
 
 type StorageConf struct {
 	items  []*Item
 	locked bool
+	key    string
 }
 
 func NewChest(pos Pos, conf *StorageConf) *Storage {
@@ -22,5 +24,6 @@ func NewChest(pos Pos, conf *StorageConf) *Storage {
 	chest.Pos = pos
 	chest.Items = conf.items
 	chest.Locked = conf.locked
+	chest.KeyID = conf.key
 	return chest
 }
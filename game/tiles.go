@@ -5,6 +5,7 @@ type Tile struct {
 	OverlayRune rune
 	Visible     bool
 	Visited     bool
+	Light       float64
 	canWalk     bool
 	canSee      bool
 }
@@ -22,3 +23,12 @@ const (
 	Blank             = 0
 	Pending           = -1
 )
+
+// reservedRune tells whether map files use the rune for a tile, the player or a chest
+func reservedRune(r rune) bool {
+	switch r {
+	case StoneWall, OldStoneWall, StoneFloor, DirtFloor, ClosedDoor, OpenedDoor, UpStair, DownStair, StonePillar, '@', '=':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package game
+
+type autoTravel struct {
+	target    Pos
+	explore   bool
+	seen      map[*Monster]bool
+	hitpoints int
+}
+
+func (game *Game) startTravel(target Pos, explore bool) {
+	game.autoTravel = &autoTravel{
+		target:    target,
+		explore:   explore,
+		seen:      game.visibleMonsters(),
+		hitpoints: game.Player.Hitpoints,
+	}
+}
+
+func (game *Game) visibleMonsters() map[*Monster]bool {
+	visible := make(map[*Monster]bool)
+	level := game.CurrentLevel
+	for _, monster := range level.Monsters {
+		if monster.IsAlive() && level.spotted(monster.Pos) {
+			visible[monster] = true
+		}
+	}
+	return visible
+}
+
+// one step of travel or exploration per turn so monsters keep acting in between
+func (game *Game) continueTravel() ActionType {
+	t := game.autoTravel
+	if t == nil {
+		return ANone
+	}
+	level := game.CurrentLevel
+	p := game.Player
+
+	target := t.target
+	if t.explore {
+		explorable := level.travelPassable(p.Pos)
+		var found bool
+		target, found = level.nearestUnvisited(p.Pos, explorable)
+		if !found {
+			behindLocks := func(pos Pos) bool {
+				_, locked := level.Locks[pos]
+				return locked || explorable(pos)
+			}
+			if _, locked := level.nearestUnvisited(p.Pos, behindLocks); locked {
+				game.logMessage("travel.locked", nil)
+			} else {
+				game.logMessage("travel.explored", nil)
+			}
+			game.autoTravel = nil
+			return ANone
+		}
+	}
+
+	path := level.findPath(p.Pos, target, level.travelPassable(target))
+	if len(path) == 0 {
+		game.logMessage("travel.unreachable", nil)
+		game.autoTravel = nil
+		return ANone
+	}
+	next := path[0]
+	if monster, exists := level.AliveMonstersPos[next]; exists {
+		game.logMessage("travel.blocked", map[string]string{"actor": game.catalog.Name(monster.Name)})
+		game.autoTravel = nil
+		return ANone
+	}
+	// the path only leads through locked doors the player has the key for
+	if _, locked := level.Locks[next]; locked {
+		return game.unlock(next)
+	}
+
+	action := game.resolveMovement(next)
+	_, storage := game.CurrentLevel.Storages[p.Pos]
+	if game.CurrentLevel != level || p.Pos == t.target && !t.explore || len(game.CurrentLevel.Items[p.Pos]) > 0 || storage {
+		game.autoTravel = nil
+	}
+	return action
+}
+
+func (game *Game) interruptTravel() {
+	t := game.autoTravel
+	if t == nil {
+		return
+	}
+	if game.Player.Hitpoints < t.hitpoints {
+		game.autoTravel = nil
+		return
+	}
+	for monster := range game.visibleMonsters() {
+		if !t.seen[monster] {
+			game.logMessage("travel.monster", map[string]string{"actor": game.catalog.Name(monster.Name)})
+			game.autoTravel = nil
+			return
+		}
+	}
+}
+
+// portals are only entered when they are the destination,
+// locked doors only with their key
+func (level *Level) travelPassable(target Pos) func(Pos) bool {
+	return func(pos Pos) bool {
+		if _, portal := level.Portals[pos]; portal && pos != target {
+			return false
+		}
+		if keyID, locked := level.Locks[pos]; locked && level.Player.findKey(keyID) == nil {
+			return false
+		}
+		return level.canPass(pos)
+	}
+}
+
+func (level *Level) nearestUnvisited(start Pos, passable func(Pos) bool) (Pos, bool) {
+	visited := map[Pos]bool{start: true}
+	frontier := []Pos{start}
+	for len(frontier) > 0 {
+		current := frontier[0]
+		frontier = frontier[1:]
+		if !level.Map[current.Y][current.X].Visited {
+			return current, true
+		}
+		for _, next := range level.neighbors(current, passable) {
+			if !visited[next] {
+				visited[next] = true
+				frontier = append(frontier, next)
+			}
+		}
+	}
+	return Pos{}, false
+}
@@ -0,0 +1,59 @@
+package game
+
+import "testing"
+
+// travel runs the game until the travel ends, at most for the given number of turns
+func travel(game *Game, target Pos, turns int) {
+	game.Step(&Input{Typ: ITravel, Target: target})
+	for i := 0; i < turns && game.autoTravel != nil; i++ {
+		game.Step(&Input{Typ: IContinueTravel})
+	}
+}
+
+func TestTravelStopsForMonster(t *testing.T) {
+	game := newTestGame(t,
+		"###########",
+		"#@........#",
+		"#####.#####",
+		"#####R#####",
+		"###########",
+	)
+	rat := game.CurrentLevel.Monsters[0]
+	rat.Awareness = Asleep
+	if game.CurrentLevel.spotted(rat.Pos) {
+		t.Fatal("rat is in sight from the start")
+	}
+
+	target := Pos{9, 1}
+	travel(game, target, 20)
+	if game.autoTravel != nil {
+		t.Fatal("travel did not stop")
+	}
+	if game.Player.Pos == target {
+		t.Error("travel went on past the rat")
+	}
+	if !game.CurrentLevel.spotted(rat.Pos) {
+		t.Errorf("travel stopped at %v before the rat came in sight", game.Player.Pos)
+	}
+}
+
+func TestTravelStopsOnDamage(t *testing.T) {
+	game := newTestGame(t,
+		"###########",
+		"#@........#",
+		"###########",
+	)
+	game.startTravel(Pos{9, 1}, false)
+	game.Player.Hitpoints--
+	game.interruptTravel()
+	if game.autoTravel != nil {
+		t.Error("travel went on after the player got hurt")
+	}
+
+	// without interruptions the player gets all the way
+	game.Player.Hitpoints = game.Player.MaxHitpoints
+	travel(game, Pos{9, 1}, 20)
+	if game.Player.Pos != (Pos{9, 1}) {
+		t.Errorf("travel ended at %v", game.Player.Pos)
+	}
+}
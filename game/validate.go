@@ -0,0 +1,241 @@
+package game
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+)
+
+const (
+	CheckLoad            = "load"
+	CheckEntityBounds    = "entity-bounds"
+	CheckEntityPlacement = "entity-placement"
+	CheckPortalSource    = "portal-source"
+	CheckPortalTarget    = "portal-target"
+	CheckReachability    = "reachability"
+	CheckStairs          = "stairs"
+	CheckStartPlacement  = "start-placement"
+)
+
+type ValidationIssue struct {
+	Check   string `json:"check"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Level   string `json:"level,omitempty"`
+	Pos     *Pos   `json:"pos,omitempty"`
+	Message string `json:"message"`
+}
+
+type ValidationReport struct {
+	OK     bool              `json:"ok"`
+	Issues []ValidationIssue `json:"issues"`
+}
+
+func (report *ValidationReport) add(check, level string, pos *Pos, message string) {
+	report.Issues = append(report.Issues, ValidationIssue{Check: check, Level: level, Pos: pos, Message: message})
+}
+
+func Validate(conf *GameConf) *ValidationReport {
+	report := &ValidationReport{Issues: make([]ValidationIssue, 0)}
+
+	// the levels that loaded are checked even when others are broken
+	game, err := loadGame(conf)
+	if err != nil {
+		report.addLoadErrors(err)
+	}
+	if game == nil {
+		return report
+	}
+
+	names := make([]string, 0, len(game.Levels))
+	for name, level := range game.Levels {
+		if level != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		game.Levels[name].validateEntities(name, report)
+		game.validatePortals(name, report)
+		game.Levels[name].validateStairs(name, report)
+	}
+	if game.CurrentLevel != nil {
+		game.validateStart(report)
+		game.validateReachability(names, report)
+	}
+
+	report.OK = len(report.Issues) == 0
+	return report
+}
+
+func (report *ValidationReport) addLoadErrors(err error) {
+	errs, ok := err.(ErrorList)
+	if !ok {
+		errs = ErrorList{err}
+	}
+	for _, err := range errs {
+		issue := ValidationIssue{Check: CheckLoad, Message: err.Error()}
+		var loadErr *LoadError
+		if errors.As(err, &loadErr) {
+			issue.File, issue.Line, issue.Column, issue.Level = loadErr.File, loadErr.Line, loadErr.Column, loadErr.Level
+			issue.Message = loadErr.Err.Error()
+			if loadErr.Rune != 0 {
+				issue.Message += " " + string(loadErr.Rune)
+			}
+			switch {
+			case errors.Is(err, ErrPortalSource), errors.Is(err, ErrDuplicatePortal):
+				issue.Check = CheckPortalSource
+			case errors.Is(err, ErrPortalTarget):
+				issue.Check = CheckPortalTarget
+			case errors.Is(err, ErrStairMismatch):
+				issue.Check = CheckStairs
+			case errors.Is(err, ErrBlockedStart):
+				issue.Check = CheckStartPlacement
+			case errors.Is(err, ErrOutOfBounds):
+				issue.Check = CheckEntityBounds
+			}
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+}
+
+func (level *Level) canPass(pos Pos) bool {
+	if !level.inRange(pos) || level.Map[pos.Y][pos.X].Rune == Blank {
+		return false
+	}
+	t := level.Map[pos.Y][pos.X]
+	return t.canWalk || t.OverlayRune == ClosedDoor
+}
+
+func (level *Level) validateEntities(name string, report *ValidationReport) {
+	check := func(pos Pos, what string) {
+		if !level.inRange(pos) {
+			report.add(CheckEntityBounds, name, &pos, what+" is out of bounds")
+		} else if !level.canPass(pos) {
+			report.add(CheckEntityPlacement, name, &pos, what+" is not on a walkable tile")
+		}
+	}
+
+	for _, monster := range level.Monsters {
+		check(monster.Pos, monster.Name)
+	}
+	positions := make([]Pos, 0, len(level.Items))
+	for pos := range level.Items {
+		positions = append(positions, pos)
+	}
+	for _, pos := range sortPositions(positions) {
+		for _, item := range level.Items[pos] {
+			check(pos, item.Name)
+		}
+	}
+	positions = positions[:0]
+	for pos := range level.Storages {
+		positions = append(positions, pos)
+	}
+	for _, pos := range sortPositions(positions) {
+		check(pos, level.Storages[pos].Name)
+	}
+}
+
+// maps without a player entity leave it in the corner, monsters may share its tile
+func (game *Game) validateStart(report *ValidationReport) {
+	pos := game.Player.Pos
+	if !game.CurrentLevel.canStart(pos) {
+		report.add(CheckStartPlacement, game.CurrentLevel.Name, &pos, "player does not start on a free walkable tile")
+	}
+}
+
+func (game *Game) validatePortals(name string, report *ValidationReport) {
+	level := game.Levels[name]
+	dstNames := make(map[*Level]string)
+	for dstName, dstLevel := range game.Levels {
+		dstNames[dstLevel] = dstName
+	}
+
+	positions := make([]Pos, 0, len(level.Portals))
+	for pos := range level.Portals {
+		positions = append(positions, pos)
+	}
+	for _, pos := range sortPositions(positions) {
+		pos := pos
+		portal := level.Portals[pos]
+		if !level.canPass(pos) {
+			report.add(CheckPortalSource, name, &pos, "portal entry is not walkable")
+		}
+		// destinations out of bounds are never linked, loading reports them
+		dstName := dstNames[portal.level]
+		dstPos := portal.pos
+		if !portal.level.canWalk(dstPos) {
+			report.add(CheckPortalTarget, dstName, &dstPos, "portal destination from "+name+" is not walkable")
+		}
+	}
+}
+
+func (level *Level) validateStairs(name string, report *ValidationReport) {
+	for y, row := range level.Map {
+		for x, t := range row {
+			if t.OverlayRune != UpStair && t.OverlayRune != DownStair {
+				continue
+			}
+			pos := Pos{x, y}
+			if _, exists := level.Portals[pos]; !exists {
+				report.add(CheckStairs, name, &pos, "stairs have no matching portal")
+			}
+		}
+	}
+}
+
+func (game *Game) validateReachability(names []string, report *ValidationReport) {
+	start := LevelPos{game.CurrentLevel, game.Player.Pos}
+	frontier := []LevelPos{start}
+	visited := map[LevelPos]bool{start: true}
+	for len(frontier) > 0 {
+		current := frontier[0]
+		frontier = frontier[1:]
+
+		next := make([]LevelPos, 0, 5)
+		for _, pos := range current.level.neighbors(current.pos, current.level.canPass) {
+			next = append(next, LevelPos{current.level, pos})
+		}
+		if portal, exists := current.level.Portals[current.pos]; exists {
+			next = append(next, portal.LevelPos)
+		}
+		for _, n := range next {
+			if !visited[n] {
+				visited[n] = true
+				frontier = append(frontier, n)
+			}
+		}
+	}
+
+	for _, name := range names {
+		level := game.Levels[name]
+		for y, row := range level.Map {
+			for x := range row {
+				pos := Pos{x, y}
+				if !level.canPass(pos) || visited[LevelPos{level, pos}] {
+					continue
+				}
+
+				// mark the whole unreachable region so it is reported only once
+				size := 0
+				region := []Pos{pos}
+				visited[LevelPos{level, pos}] = true
+				for len(region) > 0 {
+					current := region[0]
+					region = region[1:]
+					size++
+					for _, next := range level.neighbors(current, level.canPass) {
+						if !visited[LevelPos{level, next}] {
+							visited[LevelPos{level, next}] = true
+							region = append(region, next)
+						}
+					}
+				}
+				report.add(CheckReachability, name, &pos, "region of "+strconv.Itoa(size)+" walkable tiles is unreachable from the start")
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package game
+
+import "testing"
+
+func validateWorld(t *testing.T, files map[string]string) *ValidationReport {
+	conf := testConf()
+	conf.MapsDir = writeFixtures(t, files)
+	return Validate(conf)
+}
+
+func TestValidateStartPlacement(t *testing.T) {
+	const room = "#####\n#@..#\n#####\n\nENTITIES:\n"
+	tests := []struct {
+		name  string
+		world string
+		level string
+		want  string
+	}{
+		{"player entity", "start = test\n", room, ""},
+		{"spawn on floor", "start = test\nspawn = 3,1\n", room, ""},
+		{"spawn in a wall", "start = test\nspawn = 0,0\n", room, CheckStartPlacement},
+		{"monster on the player", "start = test\n", room + "R,1,1", CheckStartPlacement},
+		{"no player entity", "start = test\n", "#####\n#...#\n#####\n\nENTITIES:\n", CheckStartPlacement},
+	}
+	for _, test := range tests {
+		report := validateWorld(t, map[string]string{"world.ini": test.world, "test.map": test.level})
+		if test.want == "" {
+			if !report.OK {
+				t.Errorf("%s: %+v", test.name, report.Issues)
+			}
+			continue
+		}
+		found := false
+		for _, issue := range report.Issues {
+			found = found || issue.Check == test.want
+		}
+		if !found {
+			t.Errorf("%s: %+v, want a %s issue", test.name, report.Issues, test.want)
+		}
+	}
+}
+
+func TestValidatePortalBounds(t *testing.T) {
+	const room = "#####\n#@..#\n#####\n\nENTITIES:\n"
+	tests := []struct {
+		name string
+		link string
+		want string
+	}{
+		{"destination", "test 2,1 -> test 9,9", CheckPortalTarget},
+		{"entry", "test 9,9 -> test 2,1", CheckPortalSource},
+	}
+	for _, test := range tests {
+		world := "start = test\n\n[portal gate]\nlink = " + test.link + "\n"
+		report := validateWorld(t, map[string]string{"world.ini": world, "test.map": room})
+		if len(report.Issues) != 1 || report.Issues[0].Check != test.want || report.Issues[0].Line != 3 {
+			t.Errorf("%s: %+v, want one %s issue on line 3", test.name, report.Issues, test.want)
+		}
+	}
+}
+
+func TestValidateBrokenLevel(t *testing.T) {
+	report := validateWorld(t, map[string]string{
+		"world.ini": "start = good\n",
+		// the right room has no way in
+		"good.map": "#########\n#@.R#...#\n#########\n\nENTITIES:\nR,0,0",
+		"bad.map":  "####\n#.X#\n####\n\nENTITIES:\n",
+	})
+	checks := make(map[string]int)
+	for _, issue := range report.Issues {
+		checks[issue.Check]++
+	}
+	for _, check := range []string{CheckLoad, CheckEntityPlacement, CheckReachability} {
+		if checks[check] != 1 {
+			t.Errorf("%d %s issues, want 1 in %+v", checks[check], check, report.Issues)
+		}
+	}
+}
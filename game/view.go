@@ -0,0 +1,181 @@
+package game
+
+type ItemView struct {
+	Entity
+	ID       int
+	Typ      ItemType
+	Quantity int
+	// split off one at a time rather than taken whole
+	Stackable bool
+
+	Description string
+	MinDamage   int
+	MaxDamage   int
+	Armor       int
+	Weight      float64
+	Value       int
+}
+
+type CharacterView struct {
+	Entity
+	Items        []ItemView
+	Hitpoints    int
+	MaxHitpoints int
+	Strength     int
+	SightRange   int
+	Spotted      bool
+	Load         float64
+	Capacity     float64
+	Encumbered   bool
+
+	Helmet *ItemView
+	Weapon *ItemView
+	Armor  *ItemView
+}
+
+func (c *CharacterView) IsAlive() bool {
+	return c.Hitpoints > 0
+}
+
+type StorageView struct {
+	Entity
+	Items  []ItemView
+	Locked bool
+}
+
+type LevelView struct {
+	Name     string
+	Depth    int
+	Music    string
+	Ambient  float64
+	Map      [][]Tile
+	Player   CharacterView
+	Monsters []CharacterView
+
+	Items    map[Pos][]ItemView
+	Storages map[Pos]*StorageView
+
+	Log        []string
+	Debug      map[Pos]bool
+	LastEvents []Event
+	Traveling  bool
+}
+
+func (game *Game) itemView(item *Item) ItemView {
+	def := item.kind
+	return ItemView{
+		Entity:    item.Entity,
+		ID:        item.ID,
+		Typ:       item.Typ,
+		Quantity:  item.Quantity,
+		Stackable: def.Stackable,
+
+		Description: def.Description,
+		MinDamage:   def.MinDamage,
+		MaxDamage:   def.MaxDamage,
+		Armor:       def.Armor,
+		Weight:      item.weight(),
+		Value:       def.Value,
+	}
+}
+
+func (game *Game) itemViews(items []*Item) []ItemView {
+	views := make([]ItemView, len(items))
+	for i, item := range items {
+		views[i] = game.itemView(item)
+	}
+	return views
+}
+
+func (game *Game) equippedView(item *Item) *ItemView {
+	if item == nil {
+		return nil
+	}
+	view := game.itemView(item)
+	return &view
+}
+
+func (game *Game) characterView(c *Character) CharacterView {
+	return CharacterView{
+		Entity:       c.Entity,
+		Items:        game.itemViews(c.Items),
+		Hitpoints:    c.Hitpoints,
+		MaxHitpoints: c.MaxHitpoints,
+		Strength:     c.Strength,
+		SightRange:   c.SightRange,
+		Load:         c.load(),
+		Capacity:     c.capacity(),
+		Encumbered:   c.encumbered(),
+		Helmet:       game.equippedView(c.Helmet),
+		Weapon:       game.equippedView(c.Weapon),
+		Armor:        game.equippedView(c.Armor),
+	}
+}
+
+func (game *Game) View() *LevelView {
+	level := game.CurrentLevel
+	view := &LevelView{
+		Name:     level.Name,
+		Depth:    level.Depth,
+		Music:    level.Music,
+		Ambient:  level.Ambient,
+		Player:   game.characterView(&game.Player.Character),
+		Items:    make(map[Pos][]ItemView, len(level.Items)),
+		Storages: make(map[Pos]*StorageView, len(level.Storages)),
+		Debug:    make(map[Pos]bool, len(level.Debug)),
+
+		Traveling: game.autoTravel != nil,
+	}
+
+	view.Map = make([][]Tile, len(level.Map))
+	for y, row := range level.Map {
+		view.Map[y] = make([]Tile, len(row))
+		copy(view.Map[y], row)
+	}
+
+	view.Monsters = make([]CharacterView, len(level.Monsters))
+	for i, monster := range level.Monsters {
+		view.Monsters[i] = game.characterView(&monster.Character)
+		view.Monsters[i].Spotted = level.spotted(monster.Pos)
+	}
+
+	for pos, items := range level.Items {
+		if len(items) > 0 {
+			view.Items[pos] = game.itemViews(items)
+		}
+	}
+	for pos, storage := range level.Storages {
+		view.Storages[pos] = &StorageView{storage.Entity, game.itemViews(storage.Items), storage.Locked}
+	}
+	for pos, debug := range level.Debug {
+		view.Debug[pos] = debug
+	}
+
+	view.Log = make([]string, len(level.Log))
+	copy(view.Log, level.Log)
+	view.LastEvents = make([]Event, len(level.LastEvents))
+	copy(view.LastEvents, level.LastEvents)
+	return view
+}
+
+func (game *Game) findItem(id int) *Item {
+	if id == 0 {
+		return nil
+	}
+
+	p := game.Player
+	candidates := make([]*Item, 0, len(p.Items)+3)
+	candidates = append(candidates, p.Items...)
+	candidates = append(candidates, p.Helmet, p.Weapon, p.Armor)
+	candidates = append(candidates, game.CurrentLevel.Items[p.Pos]...)
+	if storage := game.CurrentLevel.Storages[p.Pos]; storage != nil {
+		candidates = append(candidates, storage.Items...)
+	}
+
+	for _, item := range candidates {
+		if item != nil && item.ID == id {
+			return item
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package game
+
+import "math"
+
+const (
+	// carried weight a point of strength allows
+	carryPerStrength = 2.0
+	// share of the capacity carried without slowing down
+	burdenRatio = 0.5
+	// speed multiplier while encumbered
+	burdenedSpeed = 0.5
+	// slowest any character gets, the scheduler would never grant a turn at zero
+	minSpeed = 0.1
+)
+
+func (item *Item) weight() float64 {
+	return item.kind.Weight * float64(item.Quantity)
+}
+
+// load is the weight of the inventory and the equipped items
+func (c *Character) load() float64 {
+	load := 0.0
+	for _, item := range c.Items {
+		load += item.weight()
+	}
+	for _, item := range []*Item{c.Helmet, c.Weapon, c.Armor} {
+		if item != nil {
+			load += item.weight()
+		}
+	}
+	return load
+}
+
+func (c *Character) capacity() float64 {
+	return float64(c.Strength) * carryPerStrength
+}
+
+func (c *Character) encumbered() bool {
+	return c.load() > c.capacity()*burdenRatio
+}
+
+// speed is what the scheduler grants, slowed down by a heavy load
+func (c *Character) speed() float64 {
+	speed := c.Speed
+	if c.encumbered() {
+		speed *= burdenedSpeed
+	}
+	return math.Max(speed, minSpeed)
+}
+
+// overloaded reports whether taking the quantity of the item would exceed
+// the capacity of the player, zero quantity stands for the whole stack
+func (game *Game) overloaded(item *Item, quantity int) bool {
+	weight := item.weight()
+	if quantity > 0 && quantity < item.Quantity {
+		weight = item.kind.Weight * float64(quantity)
+	}
+	if game.Player.load()+weight <= game.Player.capacity() {
+		return false
+	}
+	view := game.itemView(item)
+	game.CurrentLevel.emit(Event{Kind: Overloaded, Actor: game.Player.Name, Pos: game.Player.Pos, Item: &view})
+	return true
+}
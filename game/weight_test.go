@@ -0,0 +1,90 @@
+package game
+
+import "testing"
+
+func newTestItem(game *Game, weight float64) *Item {
+	registry := &ItemRegistry{index: newDefIndex()}
+	def := &ItemDef{Name: "Anvil", Rune: 'A', Sprite: Sprite{0, 0, 1}, Slot: Other, Weight: weight, registry: registry}
+	registry.index.add(def)
+	item := def.Spawn(game.Player.Pos)
+	game.CurrentLevel.AddItem(item)
+	return item
+}
+
+func countEvents(level *Level, kind GameEvent) int {
+	count := 0
+	for _, event := range level.LastEvents {
+		if event.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+func TestTakeOverloaded(t *testing.T) {
+	game := newTestGame(t,
+		"#####",
+		"#@.R#",
+		"#####",
+	)
+	anvil := newTestItem(game, game.Player.capacity()+1)
+	game.Step(&Input{Typ: ITakeItem, ItemID: anvil.ID})
+	if len(game.Player.Items) != 0 {
+		t.Fatal("player took an item over the capacity")
+	}
+	if count := countEvents(game.CurrentLevel, Overloaded); count != 1 {
+		t.Errorf("%d overloaded events, want 1", count)
+	}
+}
+
+func TestEncumberedTurns(t *testing.T) {
+	game := newTestGame(t,
+		"##########",
+		"#@......R#",
+		"##########",
+	)
+	anvil := newTestItem(game, game.Player.capacity()*burdenRatio+1)
+	game.Step(&Input{Typ: ITakeItem, ItemID: anvil.ID})
+	if !game.Player.encumbered() {
+		t.Fatal("player is not encumbered")
+	}
+	if count := countEvents(game.CurrentLevel, Overloaded); count != 0 {
+		t.Errorf("%d overloaded events for a load under the capacity", count)
+	}
+
+	rat := game.CurrentLevel.Monsters[0]
+	from := rat.Pos
+	game.Step(&Input{Typ: IMove, Direction: DRight})
+	if moved := from.X - rat.Pos.X; moved != 2 {
+		t.Errorf("rat moved %d tiles during a slowed down player move, want 2", moved)
+	}
+
+	// even a crawling player keeps getting turns when nobody else acts
+	rat.Hitpoints = 0
+	delete(game.CurrentLevel.AliveMonstersPos, rat.Pos)
+	game.Player.Speed = 0
+	if speed := game.Player.speed(); speed <= 0 {
+		t.Fatalf("encumbered speed %v", speed)
+	}
+	game.Step(&Input{Typ: IMove, Direction: DRight})
+	if !game.Player.ready() {
+		t.Error("player did not get another turn")
+	}
+}
+
+func TestStandstillTurns(t *testing.T) {
+	game := newTestGame(t,
+		"######",
+		"#@.#R#",
+		"######",
+	)
+	// the walled in rat keeps waiting while the player gets nowhere
+	game.Player.Speed = 0
+	game.Step(&Input{Typ: IMove, Direction: DRight})
+	if !game.Player.ready() {
+		t.Error("player did not get another turn")
+	}
+	if game.Player.Pos != (Pos{2, 1}) {
+		t.Errorf("player at %v, want 2,1", game.Player.Pos)
+	}
+}
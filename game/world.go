@@ -0,0 +1,407 @@
+package game
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	worldFile       = "world.ini"
+	legacyWorldFile = "world.txt"
+)
+
+type World struct {
+	Start   string
+	Spawn   *Pos
+	Levels  []WorldLevel
+	Stacks  []WorldStack
+	Portals []WorldPortal
+
+	file      string
+	startLine int
+	spawnLine int
+}
+
+type WorldLevel struct {
+	Name    string
+	Music   string
+	Ambient float64
+
+	line int
+}
+
+type WorldStack struct {
+	Floors []string
+
+	line int
+}
+
+type WorldPos struct {
+	Level string
+	Pos   Pos
+}
+
+type WorldPortal struct {
+	Name     string
+	From, To WorldPos
+	OneWay   bool
+	KeyID    string
+
+	line int
+}
+
+func (pos WorldPos) String() string {
+	return fmt.Sprintf("%s %d,%d", pos.Level, pos.Pos.X, pos.Pos.Y)
+}
+
+// LoadWorld reads world.ini from the maps directory, a legacy world.txt is converted on the fly
+func LoadWorld(dir string) (*World, error) {
+	filename := filepath.Join(dir, worldFile)
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return ReadLegacyWorld(filepath.Join(dir, legacyWorldFile))
+	}
+	return ReadWorld(filename)
+}
+
+func ReadWorld(filename string) (*World, error) {
+	world := &World{file: filename}
+	err := readINI(filename, func(header []string, line int) setter {
+		if header == nil {
+			return world.global(line)
+		}
+		return world.section(header, line)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return world, nil
+}
+
+func (world *World) global(line int) setter {
+	return func(key, value string) error {
+		switch key {
+		case "start":
+			world.Start, world.startLine = value, line
+		case "spawn":
+			pos, err := parsePos(value)
+			if err != nil {
+				return err
+			}
+			world.Spawn, world.spawnLine = &pos, line
+		default:
+			return ErrUnknownField
+		}
+		return nil
+	}
+}
+
+// section starts a [kind name] block and returns the setter for its fields
+func (world *World) section(header []string, line int) setter {
+	name := strings.Join(header[1:], " ")
+	switch header[0] {
+	case "level":
+		world.Levels = append(world.Levels, WorldLevel{Name: name, Ambient: 1, line: line})
+		level := &world.Levels[len(world.Levels)-1]
+		return func(key, value string) error {
+			switch key {
+			case "music":
+				level.Music = value
+			case "ambient":
+				ambient, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return ErrInvalidNumber
+				}
+				level.Ambient = ambient
+			default:
+				return ErrUnknownField
+			}
+			return nil
+		}
+	case "stack":
+		world.Stacks = append(world.Stacks, WorldStack{line: line})
+		stack := &world.Stacks[len(world.Stacks)-1]
+		return func(key, value string) error {
+			if key != "floors" {
+				return ErrUnknownField
+			}
+			for _, floor := range strings.Split(value, ",") {
+				stack.Floors = append(stack.Floors, strings.TrimSpace(floor))
+			}
+			return nil
+		}
+	case "portal":
+		world.Portals = append(world.Portals, WorldPortal{Name: name, line: line})
+		portal := &world.Portals[len(world.Portals)-1]
+		return func(key, value string) error {
+			switch key {
+			case "link":
+				return portal.parseLink(value)
+			case "requires":
+				portal.KeyID = value
+			default:
+				return ErrUnknownField
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// links look like "level1-dungeon 32,3 <-> level1-crypt 8,5", one-way portals use ->
+func (portal *WorldPortal) parseLink(value string) error {
+	arrow := "<->"
+	if !strings.Contains(value, arrow) {
+		arrow = "->"
+		portal.OneWay = true
+	}
+	ends := strings.Split(value, arrow)
+	if len(ends) != 2 {
+		return ErrMissingField
+	}
+	var err error
+	if portal.From, err = parseWorldPos(ends[0]); err != nil {
+		return err
+	}
+	portal.To, err = parseWorldPos(ends[1])
+	return err
+}
+
+func parseWorldPos(value string) (WorldPos, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return WorldPos{}, ErrMissingField
+	}
+	pos, err := parsePos(fields[1])
+	return WorldPos{fields[0], pos}, err
+}
+
+func parsePos(value string) (Pos, error) {
+	xy := strings.Split(value, ",")
+	if len(xy) != 2 {
+		return Pos{}, ErrMissingField
+	}
+	x, err := strconv.Atoi(strings.TrimSpace(xy[0]))
+	if err != nil {
+		return Pos{}, ErrInvalidNumber
+	}
+	y, err := strconv.Atoi(strings.TrimSpace(xy[1]))
+	if err != nil {
+		return Pos{}, ErrInvalidNumber
+	}
+	return Pos{x, y}, nil
+}
+
+// ReadLegacyWorld converts the CSV world file, a start level row followed by
+// six column portal rows, mirrored rows are merged into one two-way portal
+func ReadLegacyWorld(filename string) (*World, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	world := &World{file: filename}
+	var errs ErrorList
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		row := strings.Split(scanner.Text(), ",")
+		columns := make([]int, len(row))
+		column := 1
+		for i := range row {
+			columns[i] = column
+			column += len(row[i]) + 1
+			row[i] = strings.TrimSpace(row[i])
+		}
+		fieldError := func(field int, err error) *LoadError {
+			return &LoadError{File: filename, Line: lineNumber, Column: columns[field], Err: err}
+		}
+
+		if world.startLine == 0 {
+			world.Start, world.startLine = row[0], lineNumber
+			continue
+		}
+		if row[0] == "stack" {
+			world.Stacks = append(world.Stacks, WorldStack{row[1:], lineNumber})
+			continue
+		}
+		if len(row) < 6 {
+			errs.add(&LoadError{File: filename, Line: lineNumber, Column: column, Err: ErrMissingField})
+			continue
+		}
+
+		numbers := make([]int, 6)
+		valid := true
+		for _, field := range []int{1, 2, 4, 5} {
+			if numbers[field], err = strconv.Atoi(row[field]); err != nil {
+				errs.add(fieldError(field, ErrInvalidNumber))
+				valid = false
+			}
+		}
+		if !valid {
+			continue
+		}
+		from := WorldPos{row[0], Pos{numbers[1], numbers[2]}}
+		to := WorldPos{row[3], Pos{numbers[4], numbers[5]}}
+
+		merged := false
+		for i := range world.Portals {
+			portal := &world.Portals[i]
+			if portal.OneWay && portal.From == to && portal.To == from {
+				portal.OneWay = false
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			name := from.Level + "-" + to.Level
+			world.Portals = append(world.Portals, WorldPortal{Name: name, From: from, To: to, OneWay: true, line: lineNumber})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	return world, nil
+}
+
+func (world *World) Write(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+	fmt.Fprintf(buf, "start = %s\n", world.Start)
+	if world.Spawn != nil {
+		fmt.Fprintf(buf, "spawn = %d,%d\n", world.Spawn.X, world.Spawn.Y)
+	}
+	for _, level := range world.Levels {
+		fmt.Fprintf(buf, "\n[level %s]\n", level.Name)
+		if level.Music != "" {
+			fmt.Fprintf(buf, "music = %s\n", level.Music)
+		}
+		fmt.Fprintf(buf, "ambient = %s\n", strconv.FormatFloat(level.Ambient, 'f', -1, 64))
+	}
+	for _, stack := range world.Stacks {
+		fmt.Fprintf(buf, "\n[stack]\nfloors = %s\n", strings.Join(stack.Floors, ", "))
+	}
+	for _, portal := range world.Portals {
+		arrow := "<->"
+		if portal.OneWay {
+			arrow = "->"
+		}
+		fmt.Fprintf(buf, "\n[portal %s]\nlink = %s %s %s\n", portal.Name, portal.From, arrow, portal.To)
+		if portal.KeyID != "" {
+			fmt.Fprintf(buf, "requires = %s\n", portal.KeyID)
+		}
+	}
+	return buf.Flush()
+}
+
+// the player starts on a walkable tile no monster stands on
+func (level *Level) canStart(pos Pos) bool {
+	_, monster := level.AliveMonstersPos[pos]
+	return level.canWalk(pos) && !monster
+}
+
+func (game *Game) applyWorld(world *World) error {
+	var errs ErrorList
+	levelError := func(line int, name string) *LoadError {
+		return &LoadError{File: world.file, Line: line, Level: name, Err: ErrUnknownLevel}
+	}
+	lineError := func(line int, name string, err error) *LoadError {
+		return &LoadError{File: world.file, Line: line, Level: name, Err: err}
+	}
+
+	if world.startLine == 0 {
+		errs.add(&LoadError{File: world.file, Err: ErrMissingStart})
+	} else if level, exists := game.Levels[world.Start]; !exists {
+		errs.add(levelError(world.startLine, world.Start))
+	} else {
+		game.CurrentLevel = level
+		if world.Spawn != nil {
+			if level != nil && !level.canStart(*world.Spawn) {
+				errs.add(lineError(world.spawnLine, world.Start, ErrBlockedStart))
+			} else {
+				game.Player.Pos = *world.Spawn
+			}
+		}
+	}
+
+	for _, meta := range world.Levels {
+		level, exists := game.Levels[meta.Name]
+		if !exists {
+			errs.add(levelError(meta.line, meta.Name))
+		} else if level != nil {
+			level.Music = meta.Music
+			level.Ambient = meta.Ambient
+		}
+	}
+
+	// floors are listed from the top and their stairs get linked pairwise
+	for _, stack := range world.Stacks {
+		var upper *Level
+		for i, name := range stack.Floors {
+			level, exists := game.Levels[name]
+			if !exists {
+				errs.add(levelError(stack.line, name))
+			}
+			if level == nil {
+				upper = nil
+				continue
+			}
+			level.Depth = i + 1
+			if upper != nil {
+				if err := LinkStairs(upper, level); err != nil {
+					errs.add(lineError(stack.line, name, err))
+				}
+			}
+			upper = level
+		}
+	}
+
+	// the ends are told apart so the validator can point at the wrong one
+	endErrors := []error{ErrPortalSource, ErrPortalTarget}
+	for _, portal := range world.Portals {
+		ends := []WorldPos{portal.From, portal.To}
+		levels := make([]*Level, 2)
+		valid := true
+		for i, end := range ends {
+			level, exists := game.Levels[end.Level]
+			if !exists {
+				errs.add(levelError(portal.line, end.Level))
+				valid = false
+			} else if level != nil && !level.inRange(end.Pos) {
+				errs.add(lineError(portal.line, end.Level, endErrors[i]))
+				valid = false
+			}
+			levels[i] = level
+		}
+		if !valid || levels[0] == nil || levels[1] == nil {
+			continue
+		}
+
+		errs.add(game.linkPortal(world, portal, levels[0], portal.From.Pos, levels[1], portal.To.Pos))
+		if !portal.OneWay {
+			errs.add(game.linkPortal(world, portal, levels[1], portal.To.Pos, levels[0], portal.From.Pos))
+		}
+	}
+	return errs.err()
+}
+
+func (game *Game) linkPortal(world *World, portal WorldPortal, level *Level, pos Pos, dstLevel *Level, dstPos Pos) error {
+	if _, duplicate := level.Portals[pos]; duplicate {
+		return &LoadError{File: world.file, Line: portal.line, Level: level.Name, Err: ErrDuplicatePortal}
+	}
+	level.Link(pos, dstLevel, dstPos)
+	level.Portals[pos].Name = portal.Name
+	level.Portals[pos].KeyID = portal.KeyID
+	return nil
+}
@@ -0,0 +1,41 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestApplyWorldSpawn(t *testing.T) {
+	tests := []struct {
+		name  string
+		spawn Pos
+		want  error
+	}{
+		{"floor", Pos{2, 1}, nil},
+		{"wall", Pos{0, 1}, ErrBlockedStart},
+		{"monster", Pos{3, 1}, ErrBlockedStart},
+		{"outside", Pos{9, 1}, ErrBlockedStart},
+	}
+	for _, test := range tests {
+		game := newTestGame(t,
+			"#####",
+			"#@.R#",
+			"#####",
+		)
+		spawn := test.spawn
+		world := &World{Start: "test", Spawn: &spawn, file: "world.ini", startLine: 1, spawnLine: 2}
+		err := game.applyWorld(world)
+		if test.want == nil && err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if test.want == nil {
+			continue
+		}
+		var loadErr *LoadError
+		errs, _ := err.(ErrorList)
+		if len(errs) != 1 || !errors.As(errs[0], &loadErr) || loadErr.Err != test.want || loadErr.Line != 2 {
+			t.Errorf("%s: %v, want %v at line 2", test.name, err, test.want)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
 	"rpg/game"
 	"rpg/ui"
 	"runtime"
@@ -8,12 +11,39 @@ import (
 )
 
 func main() {
-	g := game.NewGame() // problematic multiple window view, something with sdl and threads
+	conf := game.DefaultGameConf()
+	flag.Int64Var(&conf.Seed, "seed", 0, "run seed, random when zero")
+	flag.StringVar(&conf.Language, "lang", conf.Language, "language of the game log")
+	flag.BoolVar(&conf.Diagonal, "diagonal", false, "allow diagonal movement")
+	recordFile := flag.String("record", "", "record all inputs into this replay file")
+	flag.Parse()
+
+	g, err := game.NewGame(conf) // problematic multiple window view, something with sdl and threads
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println("seed:", g.Seed())
+
+	if *recordFile != "" {
+		file, err := os.Create(*recordFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		if err := g.Record(file); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
 		runtime.LockOSThread() // SDL has to stay on one thread
-		ui := ui.NewUI(g.InputChan, g.LevelChan)
+		ui.Init()
+		ui := ui.NewUI(g.InputChan, g.LevelChan, g.Bestiary(), g.ItemDefs())
 		ui.Run()
 		ui.Destroy()
 		wg.Done()
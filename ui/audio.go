@@ -2,24 +2,23 @@ package ui
 
 import (
 	"math/rand"
+	"rpg/game"
 	"strconv"
 
 	"github.com/veandco/go-sdl2/mix"
 )
 
+const defaultMusic = "dungeon002.ogg"
+
 type sounds struct {
 	doorOpen  []*mix.Chunk
 	doorClose []*mix.Chunk
 	footstep  []*mix.Chunk
+	// monster name and event to sound
+	monsters map[string]map[string]*mix.Chunk
 }
 
 func (ui *ui) loadAudio() {
-	var err error
-	ui.music, err = mix.LoadMUS("ui/assets/dungeon002.ogg")
-	if err != nil {
-		panic(err)
-	}
-
 	ui.sounds = &sounds{}
 	footstepBase := "ui/assets/sounds/footstep0"
 	for i := 0; i <= 9; i++ {
@@ -51,6 +50,27 @@ func (ui *ui) loadAudio() {
 
 }
 
+func (ui *ui) loadMonsterSounds(bestiary *game.Bestiary) {
+	ui.sounds.monsters = make(map[string]map[string]*mix.Chunk)
+	for _, def := range bestiary.Defs() {
+		ui.sounds.monsters[def.Name] = make(map[string]*mix.Chunk)
+		for event, filename := range def.Sounds {
+			chunk, err := mix.LoadWAV("ui/assets/sounds/" + filename)
+			if err != nil {
+				panic(err)
+			}
+			ui.sounds.monsters[def.Name][event] = chunk
+		}
+	}
+}
+
+func (ui *ui) playMonsterSound(name, event string) {
+	if chunk, exists := ui.sounds.monsters[name][event]; exists {
+		chunk.Volume(10)
+		chunk.Play(-1, 0)
+	}
+}
+
 func (s *sounds) Free() {
 	for _, chunk := range s.doorOpen {
 		chunk.Free()
@@ -58,6 +78,30 @@ func (s *sounds) Free() {
 	for _, chunk := range s.footstep {
 		chunk.Free()
 	}
+	for _, chunks := range s.monsters {
+		for _, chunk := range chunks {
+			chunk.Free()
+		}
+	}
+}
+
+// playMusic switches the background music when a level with another track is entered
+func (ui *ui) playMusic(name string) {
+	if name == "" {
+		name = defaultMusic
+	}
+	if name == ui.musicName {
+		return
+	}
+	music, err := mix.LoadMUS("ui/assets/" + name)
+	if err != nil {
+		panic(err)
+	}
+	if ui.music != nil {
+		ui.music.Free()
+	}
+	ui.music, ui.musicName = music, name
+	ui.music.Play(-1)
 }
 
 func playRandomSound(chunks []*mix.Chunk, volume int) {
@@ -14,7 +14,7 @@ func (ui *ui) getRandomTile(r rune) sdl.Rect {
 	return srcRects[ui.tileRandomizer.Intn(len(srcRects))]
 }
 
-func (ui *ui) calculateOffset(level *game.Level) (int32, int32) {
+func (ui *ui) calculateOffset(level *game.LevelView) (int32, int32) {
 	if ui.centerX == -1 || ui.centerY == -1 {
 		ui.centerX = level.Player.X
 		ui.centerY = level.Player.Y
@@ -38,7 +38,22 @@ func (ui *ui) calculateOffset(level *game.Level) (int32, int32) {
 	return offsetX, offsetY
 }
 
-func (ui *ui) drawTiles(level *game.Level, offsetX, offsetY int32) {
+func (ui *ui) checkMapTile(level *game.LevelView) (game.Pos, bool) {
+	if ui.mouseState.onRect(ui.placements.log) {
+		return game.Pos{}, false
+	}
+	x, y := ui.mouseState.x-ui.offsetX, ui.mouseState.y-ui.offsetY
+	if x < 0 || y < 0 {
+		return game.Pos{}, false
+	}
+	pos := game.Pos{X: int(x / tileSize), Y: int(y / tileSize)}
+	if pos.Y >= len(level.Map) || pos.X >= len(level.Map[pos.Y]) || !level.Map[pos.Y][pos.X].Visited {
+		return game.Pos{}, false
+	}
+	return pos, true
+}
+
+func (ui *ui) drawTiles(level *game.LevelView, offsetX, offsetY int32) {
 	for y, row := range level.Map {
 		for x, tile := range row {
 			if tile.Rune != game.Blank {
@@ -55,7 +70,9 @@ func (ui *ui) drawTiles(level *game.Level, offsetX, offsetY int32) {
 					} else if tile.Visited && !tile.Visible {
 						ui.textureAtlas.SetColorMod(128, 128, 128)
 					} else {
-						ui.textureAtlas.SetColorMod(255, 255, 255)
+						// lit tiles fade from the remembered grey to full brightness
+						shade := uint8(128 + 127*tile.Light)
+						ui.textureAtlas.SetColorMod(shade, shade, shade)
 					}
 					ui.renderer.Copy(ui.textureAtlas, &srcRect, &dstRect)
 
@@ -69,7 +86,7 @@ func (ui *ui) drawTiles(level *game.Level, offsetX, offsetY int32) {
 	ui.textureAtlas.SetColorMod(255, 255, 255)
 }
 
-func (ui *ui) drawDeadMonsters(level *game.Level, offsetX, offsetY int32) {
+func (ui *ui) drawDeadMonsters(level *game.LevelView, offsetX, offsetY int32) {
 	for _, monster := range level.Monsters {
 		if !monster.IsAlive() {
 			if level.Map[monster.Y][monster.X].Visited {
@@ -88,9 +105,9 @@ func (ui *ui) drawDeadMonsters(level *game.Level, offsetX, offsetY int32) {
 	ui.textureAtlas.SetColorMod(255, 255, 255)
 }
 
-func (ui *ui) drawMonsters(level *game.Level, offsetX, offsetY int32) {
+func (ui *ui) drawMonsters(level *game.LevelView, offsetX, offsetY int32) {
 	for _, monster := range level.Monsters {
-		if monster.IsAlive() && level.Map[monster.Y][monster.X].Visible {
+		if monster.IsAlive() && monster.Spotted {
 			monsterSrcRect := ui.textureIndex[monster.Rune][0]
 			monsterDstRect := sdl.Rect{offsetX + int32(monster.X)*tileSize, offsetY + int32(monster.Y)*tileSize, tileSize, tileSize}
 			ui.renderer.Copy(ui.textureAtlas, &monsterSrcRect, &monsterDstRect)
@@ -98,7 +115,7 @@ func (ui *ui) drawMonsters(level *game.Level, offsetX, offsetY int32) {
 	}
 }
 
-func (ui *ui) drawItemsTile(level *game.Level, offsetX, offsetY int32) {
+func (ui *ui) drawItemsTile(level *game.LevelView, offsetX, offsetY int32) {
 	for _, items := range level.Items {
 		side := int32(tileSize / math.Sqrt(float64(len(items))))
 		diff := float64(tileSize-side) / float64(len(items))
@@ -112,17 +129,17 @@ func (ui *ui) drawItemsTile(level *game.Level, offsetX, offsetY int32) {
 	}
 }
 
-func (ui *ui) drawPlayer(level *game.Level, offsetX, offsetY int32) {
+func (ui *ui) drawPlayer(level *game.LevelView, offsetX, offsetY int32) {
 	playerSrcRect := ui.textureIndex[level.Player.Rune][0]
 	playerDstRect := sdl.Rect{offsetX + int32(level.Player.X)*tileSize, offsetY + int32(level.Player.Y)*tileSize, tileSize, tileSize}
 	ui.renderer.Copy(ui.textureAtlas, &playerSrcRect, &playerDstRect)
 }
 
-func (ui *ui) drawStorages(level *game.Level, offsetX, offsetY int32) {
+func (ui *ui) drawStorages(level *game.LevelView, offsetX, offsetY int32) {
 	for pos, storage := range level.Storages {
 		if level.Map[pos.Y][pos.X].Visited {
 			var srcRect sdl.Rect
-			if &storage.Repository == ui.usedRepository {
+			if ui.exchangeOpen && pos == level.Player.Pos {
 				srcRect = ui.textureIndex[storage.Rune][len(ui.textureIndex[storage.Rune])-1]
 			} else {
 				srcRect = ui.textureIndex[storage.Rune][0]
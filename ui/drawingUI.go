@@ -1,17 +1,18 @@
 package ui
 
 import (
+	"fmt"
 	"rpg/game"
 
 	"github.com/veandco/go-sdl2/sdl"
 )
 
-func (ui *ui) drawGroundItems(level *game.Level, x, y int32) {
+func (ui *ui) drawGroundItems(level *game.LevelView, x, y int32) {
 	indexShift := 0
 	storage := level.Storages[level.Player.Pos]
 	if storage != nil {
 		var srcRect *sdl.Rect
-		if ui.usedRepository == &storage.Repository {
+		if ui.exchangeOpen {
 			srcRect = &ui.textureIndex[storage.Rune][len(ui.textureIndex[storage.Rune])-1]
 		} else {
 			srcRect = &ui.textureIndex[storage.Rune][0]
@@ -20,14 +21,32 @@ func (ui *ui) drawGroundItems(level *game.Level, x, y int32) {
 		ui.renderer.Copy(ui.textureAtlas, srcRect, dstRect)
 		indexShift++
 	}
-	for i, item := range level.Items[level.Player.Pos] {
+	items := level.Items[level.Player.Pos]
+	for i := range items {
+		item := &items[i]
 		itemSrcRect := &ui.textureIndex[item.Rune][0]
 		itemDstRect := ui.getGroundItemRect(i + indexShift)
 		ui.renderer.Copy(ui.textureAtlas, itemSrcRect, itemDstRect)
+		ui.drawQuantity(item, itemDstRect)
 	}
 }
 
-func (ui *ui) drawLog(level *game.Level) {
+// drawQuantity badges the bottom right corner of a stack
+func (ui *ui) drawQuantity(item *game.ItemView, dstRect *sdl.Rect) {
+	if item.Quantity < 2 {
+		return
+	}
+	text := ui.stringToTexture(fmt.Sprint(item.Quantity), FontSmall)
+	_, _, w, h, err := text.Query()
+	if err != nil {
+		panic(err)
+	}
+	x, y := dstRect.X+dstRect.W-w, dstRect.Y+dstRect.H-h
+	ui.drawBox(&sdl.Rect{x, y, w, h}, sdl.Color{0, 0, 0, 160})
+	ui.renderer.Copy(text, nil, &sdl.Rect{x, y, w, h})
+}
+
+func (ui *ui) drawLog(level *game.LevelView) {
 	var textPosY int32 = 0
 	ui.drawBox(ui.placements.log, sdl.Color{64, 64, 64, 192})
 	for i := len(level.Log) - 1; i >= 0; i-- {
@@ -47,7 +66,7 @@ func (ui *ui) drawLog(level *game.Level) {
 	}
 }
 
-func (ui *ui) drawInventory(level *game.Level) {
+func (ui *ui) drawInventory(level *game.LevelView) {
 	ui.drawBox(ui.placements.inv, sdl.Color{149, 84, 19, 128})
 	playerSrcRect := ui.textureIndex[level.Player.Rune][0]
 	ui.renderer.Copy(ui.textureAtlas, &playerSrcRect, ui.placements.invChar)
@@ -55,33 +74,53 @@ func (ui *ui) drawInventory(level *game.Level) {
 	ui.drawBox(ui.placements.invCharWeapon, sdl.Color{0, 0, 0, 128})
 	ui.drawBox(ui.placements.invCharArmor, sdl.Color{0, 0, 0, 128})
 
-	for i, item := range level.Player.Items {
-		if item != ui.draggedItem {
+	weight := ui.stringToTexture(fmt.Sprintf("weight %.1f/%.0f", level.Player.Load, level.Player.Capacity), FontSmall)
+	if level.Player.Encumbered {
+		weight.SetColorMod(255, 64, 64)
+	} else {
+		weight.SetColorMod(255, 255, 255)
+	}
+	_, _, w, h, err := weight.Query()
+	if err != nil {
+		panic(err)
+	}
+	ui.renderer.Copy(weight, nil, &sdl.Rect{ui.placements.inv.X + 4, ui.placements.inv.Y + 4, w, h})
+
+	for i := range level.Player.Items {
+		item := &level.Player.Items[i]
+		if !ui.isDragged(item) {
 			itemSrcRect := &ui.textureIndex[item.Rune][0]
 			itemDstRect := ui.getInventoryItemRect(i)
 			ui.renderer.Copy(ui.textureAtlas, itemSrcRect, itemDstRect)
+			ui.drawQuantity(item, itemDstRect)
 		}
 	}
 
-	if level.Player.Helmet != nil && level.Player.Helmet != ui.draggedItem {
+	if level.Player.Helmet != nil && !ui.isDragged(level.Player.Helmet) {
 		ui.renderer.Copy(ui.textureAtlas, &ui.textureIndex[level.Player.Helmet.Rune][0], ui.placements.invCharHelmet)
 	}
-	if level.Player.Weapon != nil && level.Player.Weapon != ui.draggedItem {
+	if level.Player.Weapon != nil && !ui.isDragged(level.Player.Weapon) {
 		ui.renderer.Copy(ui.textureAtlas, &ui.textureIndex[level.Player.Weapon.Rune][0], ui.placements.invCharWeapon)
 	}
-	if level.Player.Armor != nil && level.Player.Armor != ui.draggedItem {
+	if level.Player.Armor != nil && !ui.isDragged(level.Player.Armor) {
 		ui.renderer.Copy(ui.textureAtlas, &ui.textureIndex[level.Player.Armor.Rune][0], ui.placements.invCharArmor)
 	}
 }
 
-func (ui *ui) drawExchange() {
+func (ui *ui) drawExchange(level *game.LevelView) {
 	ui.drawBox(ui.placements.exch, sdl.Color{149, 84, 19, 128})
 
-	for i, item := range ui.usedRepository.Items {
-		if item != ui.draggedItem {
+	storage := ui.usedStorage(level)
+	if storage == nil {
+		return
+	}
+	for i := range storage.Items {
+		item := &storage.Items[i]
+		if !ui.isDragged(item) {
 			itemSrcRect := &ui.textureIndex[item.Rune][0]
 			itemDstRect := ui.getExchangeItemRect(i)
 			ui.renderer.Copy(ui.textureAtlas, itemSrcRect, itemDstRect)
+			ui.drawQuantity(item, itemDstRect)
 		}
 	}
 }
@@ -93,3 +132,59 @@ func (ui *ui) drawDraggedItem() {
 		ui.renderer.Copy(ui.textureAtlas, itemSrcRect, itemDstRect)
 	}
 }
+
+// drawItemInfo describes the item under the mouse cursor
+func (ui *ui) drawItemInfo(level *game.LevelView) {
+	if ui.draggedItem != nil {
+		return
+	}
+	item := ui.checkInventoryItems(level)
+	if item == nil {
+		item = ui.checkEquippedItems(level)
+	}
+	if item == nil && ui.exchangeOpen {
+		item = ui.checkExchangeItems(level)
+	}
+	if item == nil {
+		return
+	}
+
+	lines := []string{item.Name}
+	if item.Quantity > 1 {
+		lines[0] = fmt.Sprintf("%s (%d)", item.Name, item.Quantity)
+	}
+	if item.Description != "" {
+		lines = append(lines, item.Description)
+	}
+	stats := fmt.Sprintf("weight %g, value %d", item.Weight, item.Value)
+	if item.MaxDamage > 0 {
+		stats = fmt.Sprintf("damage %d-%d, %s", item.MinDamage, item.MaxDamage, stats)
+	}
+	if item.Armor > 0 {
+		stats = fmt.Sprintf("armor %d, %s", item.Armor, stats)
+	}
+	lines = append(lines, stats)
+
+	textures := make([]*sdl.Texture, len(lines))
+	var width, height int32
+	for i, line := range lines {
+		textures[i] = ui.stringToTexture(line, FontSmall)
+		_, _, w, h, err := textures[i].Query()
+		if err != nil {
+			panic(err)
+		}
+		if w > width {
+			width = w
+		}
+		height += h
+	}
+
+	x, y := ui.mouseState.x+16, ui.mouseState.y+16
+	ui.drawBox(&sdl.Rect{x, y, width + 8, height + 8}, sdl.Color{32, 32, 32, 224})
+	y += 4
+	for _, texture := range textures {
+		_, _, w, h, _ := texture.Query()
+		ui.renderer.Copy(texture, nil, &sdl.Rect{x + 4, y, w, h})
+		y += h
+	}
+}
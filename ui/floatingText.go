@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"rpg/game"
+	"strconv"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const floatingTextDuration = time.Second
+
+type floatingText struct {
+	pos     game.Pos
+	text    string
+	color   sdl.Color
+	created time.Time
+}
+
+func (ui *ui) addDamageText(event game.Event, playerName string) {
+	color := sdl.Color{255, 255, 0, 255}
+	if event.Target == playerName {
+		color = sdl.Color{255, 0, 0, 255}
+	}
+	ui.floatingTexts = append(ui.floatingTexts, floatingText{event.Pos, "-" + strconv.Itoa(event.Damage), color, time.Now()})
+}
+
+func (ui *ui) drawFloatingTexts(offsetX, offsetY int32) {
+	alive := ui.floatingTexts[:0]
+	for _, ft := range ui.floatingTexts {
+		age := time.Since(ft.created)
+		if age > floatingTextDuration {
+			continue
+		}
+		alive = append(alive, ft)
+
+		progress := float64(age) / float64(floatingTextDuration)
+		text := ui.stringToTexture(ft.text, FontSmall)
+		_, _, w, h, err := text.Query()
+		if err != nil {
+			panic(err)
+		}
+		text.SetColorMod(ft.color.R, ft.color.G, ft.color.B)
+		text.SetAlphaMod(uint8(255 * (1 - progress)))
+		x := offsetX + int32(ft.pos.X)*tileSize + (tileSize-w)/2
+		y := offsetY + int32(ft.pos.Y)*tileSize - int32(progress*tileSize)
+		ui.renderer.Copy(text, nil, &sdl.Rect{x, y, w, h})
+		text.SetAlphaMod(255)
+	}
+	ui.floatingTexts = alive
+}
@@ -14,43 +14,55 @@ const (
 	UIAExch
 )
 
-func (ui *ui) checkGroundItems(level *game.Level) *game.Item {
+func (ui *ui) usedStorage(level *game.LevelView) *game.StorageView {
+	if ui.exchangeOpen {
+		return level.Storages[level.Player.Pos]
+	}
+	return nil
+}
+
+func (ui *ui) isDragged(item *game.ItemView) bool {
+	return ui.draggedItem != nil && item.ID == ui.draggedItem.ID
+}
+
+func (ui *ui) checkGroundItems(level *game.LevelView) *game.ItemView {
 	indexShift := 0
 	storage := level.Storages[level.Player.Pos]
 	if storage != nil {
 		indexShift++
 	}
-	for i, item := range level.Items[level.Player.Pos] {
+	items := level.Items[level.Player.Pos]
+	for i := range items {
 		itemDstRect := ui.getGroundItemRect(i + indexShift)
 		if ui.mouseState.onRect(itemDstRect) {
-			return item
+			return &items[i]
 		}
 	}
 	return nil
 }
 
-func (ui *ui) checkGroundStorage(level *game.Level) *game.Repository {
+func (ui *ui) checkGroundStorage(level *game.LevelView) *game.StorageView {
 	storage := level.Storages[level.Player.Pos]
-	if storage != nil && !storage.Locked {
+	if storage != nil {
 		itemDstRect := ui.getGroundItemRect(0)
 		if ui.mouseState.onRect(itemDstRect) {
-			return &storage.Repository
+			return storage
 		}
 	}
 	return nil
 }
 
-func (ui *ui) checkInventoryItems(level *game.Level) *game.Item {
-	for i, item := range level.Player.Items {
+func (ui *ui) checkInventoryItems(level *game.LevelView) *game.ItemView {
+	for i := range level.Player.Items {
 		itemDstRect := ui.getInventoryItemRect(i)
 		if ui.mouseState.onRect(itemDstRect) {
-			return item
+			return &level.Player.Items[i]
 		}
 	}
 	return nil
 }
 
-func (ui *ui) checkEquippedItems(level *game.Level) *game.Item {
+func (ui *ui) checkEquippedItems(level *game.LevelView) *game.ItemView {
 	if ui.mouseState.onRect(ui.placements.invCharHelmet) {
 		return level.Player.Helmet
 	} else if ui.mouseState.onRect(ui.placements.invCharWeapon) {
@@ -61,38 +73,42 @@ func (ui *ui) checkEquippedItems(level *game.Level) *game.Item {
 	return nil
 }
 
-func (ui *ui) checkExchangeItems(level *game.Level) *game.Item {
-	for i, item := range ui.usedRepository.Items {
+func (ui *ui) checkExchangeItems(level *game.LevelView) *game.ItemView {
+	storage := ui.usedStorage(level)
+	if storage == nil {
+		return nil
+	}
+	for i := range storage.Items {
 		itemDstRect := ui.getExchangeItemRect(i)
 		if ui.mouseState.onRect(itemDstRect) {
-			return item
+			return &storage.Items[i]
 		}
 	}
 	return nil
 }
 
-func (ui *ui) checkInventoryDrag() *game.Item {
+func (ui *ui) checkInventoryDrag() *game.ItemView {
 	if ui.mouseState.onRect(ui.placements.inv) {
 		return ui.draggedItem
 	}
 	return nil
 }
 
-func (ui *ui) checkExchangeDrag() *game.Item {
+func (ui *ui) checkExchangeDrag() *game.ItemView {
 	if ui.mouseState.onRect(ui.placements.exch) {
 		return ui.draggedItem
 	}
 	return nil
 }
 
-func (ui *ui) checkDropDrag() *game.Item {
+func (ui *ui) checkDropDrag() *game.ItemView {
 	if !ui.mouseState.onRect(ui.placements.inv) {
 		return ui.draggedItem
 	}
 	return nil
 }
 
-func (ui *ui) checkEquipDrag() *game.Item {
+func (ui *ui) checkEquipDrag() *game.ItemView {
 	var slot *sdl.Rect
 
 	switch ui.draggedItem.Typ {
@@ -111,3 +127,18 @@ func (ui *ui) checkEquipDrag() *game.Item {
 	}
 	return nil
 }
+
+// splitQuantity tells how much of a stack to move, shift moves half of it,
+// ctrl a single piece and zero the whole stack
+func (ui *ui) splitQuantity(item *game.ItemView) int {
+	if !item.Stackable || item.Quantity < 2 {
+		return 0
+	}
+	switch {
+	case ui.keyboardState.hold(sdl.SCANCODE_LSHIFT) || ui.keyboardState.hold(sdl.SCANCODE_RSHIFT):
+		return item.Quantity / 2
+	case ui.keyboardState.hold(sdl.SCANCODE_LCTRL) || ui.keyboardState.hold(sdl.SCANCODE_RCTRL):
+		return 1
+	}
+	return 0
+}
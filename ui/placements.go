@@ -73,12 +73,7 @@ func (ui *ui) getInventoryRectangle() *sdl.Rect {
 }
 
 func (ui *ui) getInventoryItemRect(index int) *sdl.Rect {
-	return &sdl.Rect{
-		int32(index)*ui.placements.itemSize + ui.placements.inv.X,
-		ui.placements.inv.Y + ui.placements.inv.H - ui.placements.itemSize,
-		ui.placements.itemSize,
-		ui.placements.itemSize,
-	}
+	return ui.getPanelItemRect(ui.placements.inv, index)
 }
 
 func (ui *ui) getExchangeRectangle() *sdl.Rect {
@@ -90,9 +85,19 @@ func (ui *ui) getExchangeRectangle() *sdl.Rect {
 }
 
 func (ui *ui) getExchangeItemRect(index int) *sdl.Rect {
+	return ui.getPanelItemRect(ui.placements.exch, index)
+}
+
+// items fill a panel in rows from its bottom edge up
+func (ui *ui) getPanelItemRect(panel *sdl.Rect, index int) *sdl.Rect {
+	perRow := panel.W / ui.placements.itemSize
+	if perRow < 1 {
+		perRow = 1
+	}
+	column, row := int32(index)%perRow, int32(index)/perRow
 	return &sdl.Rect{
-		int32(index)*ui.placements.itemSize + ui.placements.exch.X,
-		ui.placements.exch.Y + ui.placements.exch.H - ui.placements.itemSize,
+		column*ui.placements.itemSize + panel.X,
+		panel.Y + panel.H - (row+1)*ui.placements.itemSize,
 		ui.placements.itemSize,
 		ui.placements.itemSize,
 	}
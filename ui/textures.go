@@ -3,6 +3,7 @@ package ui
 import (
 	"bufio"
 	"os"
+	"rpg/game"
 	"strconv"
 	"strings"
 
@@ -17,47 +18,88 @@ func (ui *ui) loadTextures() {
 	if err != nil {
 		panic(err)
 	}
-	ui.textureIndex = loadTextureIndex()
+	ui.textureIndex, err = loadTextureIndex("ui/assets/atlas-index.txt")
+	if err != nil {
+		panic(err)
+	}
 
 	ui.whiteDot = getSinglePixelTexture(ui.renderer, sdl.Color{255, 255, 255, 255})
 	ui.whiteDot.SetBlendMode(sdl.BLENDMODE_BLEND)
 }
 
-func loadTextureIndex() map[rune][]sdl.Rect {
+func loadTextureIndex(filename string) (map[rune][]sdl.Rect, error) {
 	textureIndex := make(map[rune][]sdl.Rect)
 
-	infile, err := os.Open("ui/assets/atlas-index.txt")
+	infile, err := os.Open(filename)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	defer infile.Close()
+
+	var errs game.ErrorList
 	scanner := bufio.NewScanner(infile)
+	lineNumber := 0
 	for scanner.Scan() {
+		lineNumber++
 		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
 
 		tileRune := rune(line[0])
 
 		xyc := line[1:]
 		splitXYC := strings.Split(xyc, ",")
-		x, err := strconv.ParseInt(strings.TrimSpace(splitXYC[0]), 10, 64)
-		if err != nil {
-			panic(err)
+		if len(splitXYC) < 3 {
+			errs = append(errs, &game.LoadError{File: filename, Line: lineNumber, Column: len(line) + 1, Rune: tileRune, Err: game.ErrMissingField})
+			continue
+		}
+		values := make([]int64, len(splitXYC))
+		column := 2
+		for i, field := range splitXYC {
+			values[i], err = strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+			if err != nil {
+				errs = append(errs, &game.LoadError{File: filename, Line: lineNumber, Column: column, Rune: tileRune, Err: game.ErrInvalidNumber})
+				break
+			}
+			column += len(field) + 1
 		}
-		y, err := strconv.ParseInt(strings.TrimSpace(splitXYC[1]), 10, 64)
 		if err != nil {
-			panic(err)
+			continue
 		}
 
-		variationCount, err := strconv.ParseInt(strings.TrimSpace(splitXYC[2]), 10, 64)
-		for i := 0; i < int(variationCount); i++ {
-			textureIndex[tileRune] = append(textureIndex[tileRune], sdl.Rect{int32(x) * 32, int32(y) * 32, 32, 32})
-			x++
-			if x > 62 {
-				x = 0
-				y++
-			}
+		textureIndex[tileRune] = spriteRects(int(values[0]), int(values[1]), int(values[2]))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return textureIndex, nil
+}
+
+func spriteRects(x, y, variationCount int) []sdl.Rect {
+	rects := make([]sdl.Rect, 0, variationCount)
+	for i := 0; i < variationCount; i++ {
+		rects = append(rects, sdl.Rect{int32(x) * 32, int32(y) * 32, 32, 32})
+		x++
+		if x > 62 {
+			x = 0
+			y++
 		}
 	}
-	return textureIndex
+	return rects
+}
+
+// monster and item sprites come from their definitions
+func (ui *ui) loadDefinitionTextures(bestiary *game.Bestiary, itemDefs *game.ItemRegistry) {
+	for _, def := range bestiary.Defs() {
+		ui.textureIndex[def.Rune] = spriteRects(def.Sprite.X, def.Sprite.Y, def.Sprite.Count)
+	}
+	for _, def := range itemDefs.Defs() {
+		ui.textureIndex[def.Rune] = spriteRects(def.Sprite.X, def.Sprite.Y, def.Sprite.Count)
+	}
 }
 
 func getSinglePixelTexture(renderer *sdl.Renderer, color sdl.Color) *sdl.Texture {
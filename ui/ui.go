@@ -22,10 +22,12 @@ type ui struct {
 	winWidth, winHeight int32
 	placements          placements
 	centerX, centerY    int
+	offsetX, offsetY    int32
+	lastTravelStep      uint32
 
 	renderer  *sdl.Renderer
 	window    *sdl.Window
-	levelChan chan *game.Level
+	levelChan chan *game.LevelView
 	inputChan chan *game.Input
 
 	tileRandomizer *rand.Rand
@@ -34,18 +36,20 @@ type ui struct {
 	textureIndex   map[rune][]sdl.Rect
 	fonts          map[FontType]*ttf.Font
 	textCache      map[TextCacheKey]*sdl.Texture
+	floatingTexts  []floatingText
 	dragFrom       UIArea
-	draggedItem    *game.Item
-	usedRepository *game.Repository
+	draggedItem    *game.ItemView
+	exchangeOpen   bool
 
-	music  *mix.Music
-	sounds *sounds
+	music     *mix.Music
+	musicName string
+	sounds    *sounds
 
 	keyboardState *keyboardState
 	mouseState    *mouseState
 }
 
-func init() {
+func Init() {
 	err := sdl.Init(sdl.INIT_EVERYTHING)
 	if err != nil {
 		panic(err)
@@ -75,7 +79,7 @@ func Destroy() {
 	sdl.Quit()
 }
 
-func NewUI(inputChan chan *game.Input, levelChan chan *game.Level) *ui {
+func NewUI(inputChan chan *game.Input, levelChan chan *game.LevelView, bestiary *game.Bestiary, itemDefs *game.ItemRegistry) *ui {
 	var err error = nil
 	ui := &ui{
 		state:          UIMain,
@@ -104,7 +108,9 @@ func NewUI(inputChan chan *game.Input, levelChan chan *game.Level) *ui {
 
 	ui.loadFonts()
 	ui.loadTextures()
+	ui.loadDefinitionTextures(bestiary, itemDefs)
 	ui.loadAudio()
+	ui.loadMonsterSounds(bestiary)
 
 	ui.recalculatePlacements()
 	return ui
@@ -112,7 +118,9 @@ func NewUI(inputChan chan *game.Input, levelChan chan *game.Level) *ui {
 
 func (ui *ui) Destroy() {
 	ui.sounds.Free()
-	ui.music.Free()
+	if ui.music != nil {
+		ui.music.Free()
+	}
 	ui.textureAtlas.Destroy()
 	for _, texture := range ui.textCache {
 		texture.Destroy()
@@ -124,8 +132,9 @@ func (ui *ui) Destroy() {
 	ui.window.Destroy()
 }
 
-func (ui *ui) drawLevel(level *game.Level) {
+func (ui *ui) drawLevel(level *game.LevelView) {
 	offsetX, offsetY := ui.calculateOffset(level)
+	ui.offsetX, ui.offsetY = offsetX, offsetY
 	ui.tileRandomizer.Seed(1)
 
 	ui.drawTiles(level, offsetX, offsetY)
@@ -134,17 +143,55 @@ func (ui *ui) drawLevel(level *game.Level) {
 	ui.drawItemsTile(level, offsetX, offsetY)
 	ui.drawMonsters(level, offsetX, offsetY)
 	ui.drawPlayer(level, offsetX, offsetY)
+	ui.drawFloatingTexts(offsetX, offsetY)
 }
 
-func (ui *ui) drawUI(level *game.Level) {
+func (ui *ui) drawUI(level *game.LevelView) {
 	ui.drawGroundItems(level, 0, 3*ui.winHeight/4)
 	ui.drawLog(level)
 }
 
+type directionKey struct {
+	scancode  uint8
+	direction game.DirectionType
+}
+
+// arrows, numpad and vi keys
+var directionKeys = []directionKey{
+	{sdl.SCANCODE_UP, game.DUp},
+	{sdl.SCANCODE_DOWN, game.DDown},
+	{sdl.SCANCODE_LEFT, game.DLeft},
+	{sdl.SCANCODE_RIGHT, game.DRight},
+	{sdl.SCANCODE_KP_8, game.DUp},
+	{sdl.SCANCODE_KP_2, game.DDown},
+	{sdl.SCANCODE_KP_4, game.DLeft},
+	{sdl.SCANCODE_KP_6, game.DRight},
+	{sdl.SCANCODE_KP_7, game.DUpLeft},
+	{sdl.SCANCODE_KP_9, game.DUpRight},
+	{sdl.SCANCODE_KP_1, game.DDownLeft},
+	{sdl.SCANCODE_KP_3, game.DDownRight},
+	{sdl.SCANCODE_K, game.DUp},
+	{sdl.SCANCODE_J, game.DDown},
+	{sdl.SCANCODE_H, game.DLeft},
+	{sdl.SCANCODE_L, game.DRight},
+	{sdl.SCANCODE_Y, game.DUpLeft},
+	{sdl.SCANCODE_U, game.DUpRight},
+	{sdl.SCANCODE_B, game.DDownLeft},
+	{sdl.SCANCODE_N, game.DDownRight},
+}
+
+func (ui *ui) pressedDirection() game.DirectionType {
+	for _, key := range directionKeys {
+		if ui.keyboardState.pressed(key.scancode) {
+			return key.direction
+		}
+	}
+	return game.DNone
+}
+
 func (ui *ui) Run() {
-	input := game.Input{game.INone, nil, game.DNone}
+	input := game.Input{Typ: game.INone}
 	currentLevel := <-ui.levelChan
-	ui.music.Play(-1)
 
 	for {
 		ui.mouseState.update()
@@ -169,14 +216,17 @@ func (ui *ui) Run() {
 		if ui.state == UIInventory {
 			if ui.mouseState.leftDoubleClicked() {
 				item := ui.checkInventoryItems(currentLevel)
-				if item != nil {
+				if item != nil && item.Typ == game.Consumable {
+					input.Typ = game.IUseItem
+					input.ItemID = item.ID
+				} else if item != nil {
 					input.Typ = game.IEquipItem
-					input.Item = item
-				} else if ui.usedRepository != nil {
+					input.ItemID = item.ID
+				} else if ui.exchangeOpen {
 					item = ui.checkExchangeItems(currentLevel)
 					if item != nil {
 						input.Typ = game.IWithdrawItem
-						input.Item = item
+						input.ItemID = item.ID
 					}
 				}
 			} else if ui.mouseState.leftClicked() {
@@ -185,7 +235,7 @@ func (ui *ui) Run() {
 				if item == nil {
 					item = ui.checkEquippedItems(currentLevel)
 					ui.dragFrom = UIASlot
-					if item == nil && ui.usedRepository != nil {
+					if item == nil && ui.exchangeOpen {
 						item = ui.checkExchangeItems(currentLevel)
 						ui.dragFrom = UIAExch
 					}
@@ -195,28 +245,31 @@ func (ui *ui) Run() {
 				item := ui.checkEquipDrag()
 				if item != nil {
 					input.Typ = game.IEquipItem
-					input.Item = item
+					input.ItemID = item.ID
 				} else {
 					item = ui.checkInventoryDrag()
 					if item != nil {
 						if ui.dragFrom == UIAExch {
 							input.Typ = game.IWithdrawItem
+							input.Quantity = ui.splitQuantity(item)
 						} else {
 							input.Typ = game.IStripItem
 						}
-						input.Item = item
+						input.ItemID = item.ID
 					} else {
-						if ui.usedRepository != nil {
+						if ui.exchangeOpen {
 							item = ui.checkExchangeDrag()
 							if item != nil {
 								input.Typ = game.IStoreItem
-								input.Item = item
+								input.ItemID = item.ID
+								input.Quantity = ui.splitQuantity(item)
 							}
 						} else {
 							item = ui.checkDropDrag()
 							if item != nil {
 								input.Typ = game.IDropItem
-								input.Item = item
+								input.ItemID = item.ID
+								input.Quantity = ui.splitQuantity(item)
 							}
 						}
 					}
@@ -226,17 +279,17 @@ func (ui *ui) Run() {
 				item := ui.checkEquippedItems(currentLevel)
 				if item != nil {
 					input.Typ = game.IStripItem
-					input.Item = item
+					input.ItemID = item.ID
 				}
 				if item == nil {
 					item = ui.checkInventoryItems(currentLevel)
 					if item != nil {
-						if ui.usedRepository != nil {
+						if ui.exchangeOpen {
 							input.Typ = game.IStoreItem
-							input.Item = item
+							input.ItemID = item.ID
 						} else {
 							input.Typ = game.IDropItem
-							input.Item = item
+							input.ItemID = item.ID
 						}
 					}
 				}
@@ -248,12 +301,21 @@ func (ui *ui) Run() {
 			item := ui.checkGroundItems(currentLevel)
 			if item != nil {
 				input.Typ = game.ITakeItem
-				input.Item = item
+				input.ItemID = item.ID
+				input.Quantity = ui.splitQuantity(item)
 			} else {
 				storage := ui.checkGroundStorage(currentLevel)
-				if storage != nil {
-					ui.usedRepository = storage
+				if storage != nil && storage.Locked {
+					input.Typ = game.IAction
+					input.Direction = game.DNone
+				} else if storage != nil {
+					ui.exchangeOpen = true
 					ui.state = UIInventory
+				} else if ui.state == UIMain {
+					if pos, ok := ui.checkMapTile(currentLevel); ok {
+						input.Typ = game.ITravel
+						input.Target = pos
+					}
 				}
 			}
 		}
@@ -261,51 +323,46 @@ func (ui *ui) Run() {
 		if ui.keyboardState.pressed(sdl.SCANCODE_ESCAPE) {
 			if ui.state != UIMain {
 				ui.state = UIMain
-				ui.usedRepository = nil
+				ui.exchangeOpen = false
 			} else {
 				input.Typ = game.IQuitGame
 			}
-		} else if ui.keyboardState.pressed(sdl.SCANCODE_UP) {
-			input.Direction = game.DUp
-			if ui.keyboardState.hold(sdl.SCANCODE_SPACE) {
-				input.Typ = game.IAction
-			} else {
-				input.Typ = game.IMove
+		} else if ui.state == UIInventory && ui.keyboardState.pressed(sdl.SCANCODE_U) {
+			// U uses the hovered item here instead of moving diagonally
+			if item := ui.checkInventoryItems(currentLevel); item != nil && item.Typ == game.Consumable {
+				input.Typ = game.IUseItem
+				input.ItemID = item.ID
 			}
-		} else if ui.keyboardState.pressed(sdl.SCANCODE_DOWN) {
-			input.Direction = game.DDown
-			if ui.keyboardState.hold(sdl.SCANCODE_SPACE) {
-				input.Typ = game.IAction
-			} else {
-				input.Typ = game.IMove
-			}
-		} else if ui.keyboardState.pressed(sdl.SCANCODE_LEFT) {
-			input.Direction = game.DLeft
-			if ui.keyboardState.hold(sdl.SCANCODE_SPACE) {
-				input.Typ = game.IAction
-			} else {
-				input.Typ = game.IMove
-			}
-		} else if ui.keyboardState.pressed(sdl.SCANCODE_RIGHT) {
-			input.Direction = game.DRight
+		} else if direction := ui.pressedDirection(); direction != game.DNone {
+			input.Direction = direction
 			if ui.keyboardState.hold(sdl.SCANCODE_SPACE) {
 				input.Typ = game.IAction
 			} else {
 				input.Typ = game.IMove
 			}
 		} else if ui.keyboardState.pressed(sdl.SCANCODE_T) {
-			if ui.state == UIInventory && ui.usedRepository != nil {
+			if ui.state == UIInventory && ui.exchangeOpen {
 				input.Typ = game.IWithdrawAllItems
 			} else {
 				input.Typ = game.ITakeAllItems
 			}
+		} else if ui.keyboardState.pressed(sdl.SCANCODE_RETURN) {
+			// use stairs or unlock the chest under the player
+			input.Typ = game.IAction
+			input.Direction = game.DNone
+		} else if ui.keyboardState.pressed(sdl.SCANCODE_O) {
+			input.Typ = game.IExplore
+		} else if ui.keyboardState.pressed(sdl.SCANCODE_F5) {
+			input.Typ = game.IQuickSave
+		} else if ui.keyboardState.pressed(sdl.SCANCODE_F9) {
+			input.Typ = game.IQuickLoad
 		} else if ui.keyboardState.pressed(sdl.SCANCODE_TAB) {
-			if ui.usedRepository == nil {
+			if !ui.exchangeOpen {
 				storage := currentLevel.Storages[currentLevel.Player.Pos]
 				if storage != nil && !storage.Locked {
-					ui.usedRepository = &storage.Repository
+					ui.exchangeOpen = true
 				}
-				if ui.usedRepository == nil {
+				if !ui.exchangeOpen {
 					if ui.state != UIMain {
 						ui.state = UIMain
 					} else {
@@ -315,25 +372,48 @@ func (ui *ui) Run() {
 					ui.state = UIInventory
 				}
 			} else {
-				ui.usedRepository = nil
+				ui.exchangeOpen = false
 				ui.state = UIMain
 			}
 		}
 
+		// travelling goes on by itself until the game stops it or another input comes
+		if input.Typ == game.INone && currentLevel.Traveling && sdl.GetTicks()-ui.lastTravelStep > 50 {
+			input.Typ = game.IContinueTravel
+			ui.lastTravelStep = sdl.GetTicks()
+		}
+
 		if input.Typ != game.INone {
 			ui.inputChan <- &input
 			switch input.Typ {
 			case game.IQuitGame:
 				return
+			case game.IQuickLoad:
+				currentLevel = <-ui.levelChan
+				ui.exchangeOpen = false
+				ui.draggedItem = nil
+				ui.centerX, ui.centerY = -1, -1
 			default:
 				currentLevel = <-ui.levelChan
 				for _, lastEvent := range currentLevel.LastEvents {
-					switch lastEvent {
+					switch lastEvent.Kind {
 					case game.Portal:
-						ui.usedRepository = nil
+						ui.exchangeOpen = false
+						ui.floatingTexts = nil
+						ui.centerX, ui.centerY = -1, -1
+					case game.Attack:
+						ui.addDamageText(lastEvent, currentLevel.Player.Name)
+						ui.playMonsterSound(lastEvent.Actor, "attack")
+						if lastEvent.Killed {
+							ui.playMonsterSound(lastEvent.Target, "death")
+						}
+					case game.Notice:
+						ui.playMonsterSound(lastEvent.Actor, "notice")
+					case game.Teleport:
+						ui.floatingTexts = nil
 						ui.centerX, ui.centerY = -1, -1
 					case game.Move:
-						ui.usedRepository = nil
+						ui.exchangeOpen = false
 						playRandomSound(ui.sounds.footstep, 10)
 					case game.DoorOpen:
 						playRandomSound(ui.sounds.doorOpen, 10)
@@ -343,17 +423,20 @@ func (ui *ui) Run() {
 				}
 			}
 			input.Typ = game.INone
+			input.Quantity = 0
 		}
 
+		ui.playMusic(currentLevel.Music)
 		ui.renderer.Clear()
 		ui.drawLevel(currentLevel)
 		ui.drawUI(currentLevel)
 		switch ui.state {
 		case UIInventory:
-			if ui.usedRepository != nil {
-				ui.drawExchange()
+			if ui.exchangeOpen {
+				ui.drawExchange(currentLevel)
 			}
 			ui.drawInventory(currentLevel)
+			ui.drawItemInfo(currentLevel)
 			ui.drawDraggedItem()
 		}
 		ui.renderer.Present()